@@ -1,3 +1,3 @@
-module kasa-smart-plug
+module github.com/viral32111/kasa-smart-plug
 
 go 1.22
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/viral32111/kasa-smart-plug/source/kasa"
+)
+
+// How often to switch within the window of a new away rule, matching the Kasa app
+const defaultAwayFrequency = 5
+
+// Structure for an away rule, for the JSON output format
+type awayRuleReport struct {
+	Identifier string `json:"id"`
+	Name string `json:"name"`
+	Enabled bool `json:"enabled"`
+	Days []string `json:"days"`
+	Start string `json:"start"`
+	End string `json:"end"`
+	Frequency int `json:"frequency"`
+}
+
+// Structure for away mode, for the JSON output format
+type awayReport struct {
+	Enabled bool `json:"enabled"`
+	Rules []awayRuleReport `json:"rules"`
+}
+
+// Lists, adds, edits, removes, enables or disables the away rules
+func runAwayCommand( ctx context.Context, client *kasa.Client, commandArguments []string, outputFormat string, isConfirmed bool ) {
+
+	// Default to listing the rules
+	action := "list"
+	actionArguments := []string{}
+	if ( len( commandArguments ) > 0 ) {
+		action, actionArguments = commandArguments[ 0 ], commandArguments[ 1 : ]
+	}
+
+	// Is this to list the rules?
+	if ( action == "list" ) {
+
+		// Require no arguments
+		if ( len( actionArguments ) > 0 ) {
+			exitWithErrorMessage( "Away list command does not require any arguments." )
+		}
+
+	// Is this to add a rule?
+	} else if ( action == "add" ) {
+
+		// Require the start & end times, with optional days & name
+		if ( len( actionArguments ) < 2 || len( actionArguments ) > 4 ) {
+			exitWithErrorMessage( "Away add command requires arguments for the start & end times, then optionally the days & name." )
+		}
+
+		// Parse the rule, enabled by default
+		rule := kasa.AwayRule{ Enabled: true, Frequency: defaultAwayFrequency }
+		parseAwayRule( &rule, actionArguments )
+
+		// Add the rule
+		identifier, addError := client.AddAwayRule( ctx, rule )
+		if ( addError != nil ) {
+			exitWithErrorMessage( addError.Error() )
+		}
+		if ( outputFormat == "human" ) {
+			fmt.Printf( "Added away rule '%s'.\n", identifier )
+		}
+
+	// Is this to edit a rule?
+	} else if ( action == "edit" ) {
+
+		// Require the rule, start & end times, with optional days & name
+		if ( len( actionArguments ) < 3 || len( actionArguments ) > 5 ) {
+			exitWithErrorMessage( "Away edit command requires arguments for the rule, start & end times, then optionally the days & name." )
+		}
+
+		// Change the existing rule, keeping the days & name unless they are given
+		rule := findAwayRule( ctx, client, actionArguments[ 0 ] )
+		parseAwayRule( &rule, actionArguments[ 1 : ] )
+
+		// Replace the rule
+		editError := client.EditAwayRule( ctx, rule )
+		if ( editError != nil ) {
+			exitWithErrorMessage( editError.Error() )
+		}
+
+	// Is this to remove a rule, or all of them?
+	} else if ( action == "rm" ) {
+
+		// Require the rule
+		if ( len( actionArguments ) != 1 ) {
+			exitWithErrorMessage( "Away remove command requires 1 argument for the rule, or 'all'." )
+		}
+
+		// Remove every rule, which requires confirmation
+		if ( actionArguments[ 0 ] == "all" ) {
+			if ( !isConfirmed ) {
+				exitWithErrorMessage( "Removing every away rule cannot be undone, use the -yes flag to confirm." )
+			}
+
+			deleteError := client.DeleteAllAwayRules( ctx )
+			if ( deleteError != nil ) {
+				exitWithErrorMessage( deleteError.Error() )
+			}
+
+		// Otherwise, remove the one rule
+		} else {
+			rule := findAwayRule( ctx, client, actionArguments[ 0 ] )
+
+			deleteError := client.DeleteAwayRule( ctx, rule.Identifier )
+			if ( deleteError != nil ) {
+				exitWithErrorMessage( deleteError.Error() )
+			}
+		}
+
+	// Is this to enable or disable a rule, or away mode as a whole?
+	} else if ( action == "enable" || action == "disable" ) {
+		enabled := ( action == "enable" )
+
+		// Change away mode as a whole if no rule is given
+		if ( len( actionArguments ) == 0 ) {
+			setError := client.SetAwayEnabled( ctx, enabled )
+			if ( setError != nil ) {
+				exitWithErrorMessage( setError.Error() )
+			}
+
+		// Otherwise, change the one rule
+		} else if ( len( actionArguments ) == 1 ) {
+			rule := findAwayRule( ctx, client, actionArguments[ 0 ] )
+			rule.Enabled = enabled
+
+			editError := client.EditAwayRule( ctx, rule )
+			if ( editError != nil ) {
+				exitWithErrorMessage( editError.Error() )
+			}
+
+		// Require at most the rule
+		} else {
+			exitWithErrorMessage( "Away enable & disable commands accept at most 1 argument for the rule." )
+		}
+
+	// Require a valid action
+	} else {
+		exitWithErrorMessage( "Invalid away action, must be either 'list', 'add', 'edit', 'rm', 'enable' or 'disable'." )
+	}
+
+	// Display the rules afterwards, so the change can be checked
+	displayAway( ctx, client, outputFormat )
+
+}
+
+// Parses the start & end times, & optionally the days & name into a rule
+func parseAwayRule( rule *kasa.AwayRule, ruleArguments []string ) {
+
+	// Parse the start time
+	startReference, startMinutes, startOffset, startError := parseRuleTime( ruleArguments[ 0 ] )
+	if ( startError != nil ) {
+		exitWithErrorMessage( startError.Error() )
+	}
+	rule.StartReference, rule.StartMinutes, rule.StartOffset = startReference, startMinutes, startOffset
+
+	// Parse the end time
+	endReference, endMinutes, endOffset, endError := parseRuleTime( ruleArguments[ 1 ] )
+	if ( endError != nil ) {
+		exitWithErrorMessage( endError.Error() )
+	}
+	rule.EndReference, rule.EndMinutes, rule.EndOffset = endReference, endMinutes, endOffset
+
+	// Parse the days, every day by default when adding
+	if ( len( ruleArguments ) > 2 ) {
+		weekdays, weekdaysError := parseWeekdays( ruleArguments[ 2 ] )
+		if ( weekdaysError != nil ) {
+			exitWithErrorMessage( weekdaysError.Error() )
+		}
+
+		rule.Weekdays = weekdays
+	} else if ( rule.Identifier == "" ) {
+		rule.Weekdays = [ 7 ]bool{ true, true, true, true, true, true, true }
+	}
+
+	// Use the name
+	if ( len( ruleArguments ) > 3 ) {
+		rule.Name = ruleArguments[ 3 ]
+	}
+
+}
+
+// Finds an away rule by its identifier or its number in the list (starting from 1), exiting if there is none
+func findAwayRule( ctx context.Context, client *kasa.Client, value string ) ( kasa.AwayRule ) {
+
+	// Fetch the rules
+	rules, _, rulesError := client.GetAwayRules( ctx )
+	if ( rulesError != nil ) {
+		exitWithErrorMessage( rulesError.Error() )
+	}
+
+	// Find the rule
+	identifiers := make( []string, len( rules ) )
+	for index, rule := range rules {
+		identifiers[ index ] = rule.Identifier
+	}
+	index := findRuleIndex( identifiers, value )
+	if ( index < 0 ) {
+		exitWithErrorMessage( fmt.Sprintf( "No away rule '%s', use the list command to see them.", value ) )
+	}
+
+	return rules[ index ]
+
+}
+
+// Displays the away rules
+func displayAway( ctx context.Context, client *kasa.Client, outputFormat string ) {
+
+	// Fetch the rules
+	rules, enabled, rulesError := client.GetAwayRules( ctx )
+	if ( rulesError != nil ) {
+		exitWithErrorMessage( rulesError.Error() )
+	}
+
+	// Convert each rule
+	report := awayReport{ Enabled: enabled, Rules: make( []awayRuleReport, len( rules ) ) }
+	for index, rule := range rules {
+		report.Rules[ index ] = awayRuleReport{
+			Identifier: rule.Identifier,
+			Name: rule.Name,
+			Enabled: rule.Enabled,
+			Days: formatWeekdays( rule.Weekdays ),
+			Start: formatRuleTime( rule.StartReference, rule.StartMinutes, rule.StartOffset ),
+			End: formatRuleTime( rule.EndReference, rule.EndMinutes, rule.EndOffset ),
+			Frequency: rule.Frequency,
+		}
+	}
+
+	// Display the rules as JSON if requested
+	if ( outputFormat == "json" ) {
+		printJSON( report )
+		return
+	}
+
+	// Display each rule on its own line
+	if ( enabled ) {
+		fmt.Printf( "Away mode is enabled with %d rule(s).\n", len( rules ) )
+	} else {
+		fmt.Printf( "Away mode is disabled with %d rule(s).\n", len( rules ) )
+	}
+	for index, rule := range report.Rules {
+		state := "disabled"
+		if ( rule.Enabled ) {
+			state = "enabled"
+		}
+
+		fmt.Printf( "%d\t%s\t%s\tfrom %s to %s on %s\t'%s'\n", index + 1, rule.Identifier, state, rule.Start, rule.End, strings.Join( rule.Days, "," ), rule.Name )
+	}
+
+}
+
+// Returns a readable name for the active mode of the smart plug
+func formatActiveMode( activeMode string ) ( string ) {
+	switch ( activeMode ) {
+		case kasa.ActiveModeAway:
+			return "away"
+		case kasa.ActiveModeCountdown:
+			return "countdown"
+		case "":
+			return kasa.ActiveModeNone
+	}
+
+	return activeMode
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/viral32111/kasa-smart-plug/source/kasa"
+)
+
+// Structure for the state of a smart bulb, for the JSON output format
+type bulbReport struct {
+	Alias string `json:"alias"`
+	Power bool `json:"power"`
+	Mode string `json:"mode"`
+	Hue int `json:"hue"` // degrees
+	Saturation int `json:"saturation"` // percent
+	ColourTemperature int `json:"colour_temperature"` // kelvin
+	Brightness int `json:"brightness"` // percent
+	Presets []lightPresetReport `json:"presets"`
+}
+
+// Structure for a saved light state, for the JSON output format
+type lightPresetReport struct {
+	Number int `json:"number"`
+	Hue int `json:"hue"` // degrees
+	Saturation int `json:"saturation"` // percent
+	ColourTemperature int `json:"colour_temperature"` // kelvin
+	Brightness int `json:"brightness"` // percent
+}
+
+// Shows or changes the light of a smart bulb, fading over an optional transition given last (e.g., 2s)
+func runBulbCommand( ctx context.Context, client *kasa.Client, commandArguments []string, outputFormat string ) {
+
+	// Default to showing the light
+	action := "show"
+	actionArguments := []string{}
+	if ( len( commandArguments ) > 0 ) {
+		action, actionArguments = commandArguments[ 0 ], commandArguments[ 1 : ]
+	}
+	actionArguments, transition := parseTransition( actionArguments )
+
+	// Is this to show the light?
+	if ( action == "show" ) {
+
+		// Require no arguments
+		if ( len( actionArguments ) > 0 || transition > 0 ) {
+			exitWithErrorMessage( "Bulb show command does not require any arguments." )
+		}
+
+	// Is this to switch the light on or off?
+	} else if ( action == "on" || action == "off" ) {
+
+		// Require no arguments other than the transition
+		if ( len( actionArguments ) > 0 ) {
+			exitWithErrorMessage( fmt.Sprintf( "Bulb %s command only accepts the transition.", action ) )
+		}
+
+		// Switch the light
+		setError := client.SetBulbPowerState( ctx, action == "on", transition )
+		if ( setError != nil ) {
+			exitWithErrorMessage( setError.Error() )
+		}
+
+	// Is this to dim or brighten the light?
+	} else if ( action == "brightness" ) {
+
+		// Require the brightness
+		if ( len( actionArguments ) != 1 ) {
+			exitWithErrorMessage( "Bulb brightness command requires an argument for the brightness percentage, then optionally the transition." )
+		}
+		brightness := parsePercentage( actionArguments[ 0 ], "brightness" )
+
+		// Change the brightness, which also switches the light on
+		changeLightState( ctx, client, kasa.LightStateChange{ Brightness: &brightness, Transition: transition } )
+
+	// Is this to change the colour of the light?
+	} else if ( action == "colour" || action == "color" ) {
+
+		// Require the hue & saturation, with an optional brightness
+		if ( len( actionArguments ) < 2 || len( actionArguments ) > 3 ) {
+			exitWithErrorMessage( "Bulb colour command requires arguments for the hue (0 to 360) & saturation percentage, then optionally the brightness percentage & transition." )
+		}
+		hue, hueParseError := strconv.Atoi( actionArguments[ 0 ] )
+		if ( hueParseError != nil || hue < 0 || hue > 360 ) {
+			exitWithErrorMessage( "Invalid hue, must be a whole number of degrees between 0 and 360." )
+		}
+		saturation := parsePercentage( actionArguments[ 1 ], "saturation" )
+		change := kasa.LightStateChange{ Hue: &hue, Saturation: &saturation, Transition: transition }
+		if ( len( actionArguments ) > 2 ) {
+			brightness := parsePercentage( actionArguments[ 2 ], "brightness" )
+			change.Brightness = &brightness
+		}
+
+		// Change the colour
+		changeLightState( ctx, client, change )
+
+	// Is this to change the colour temperature of white light?
+	} else if ( action == "temperature" ) {
+
+		// Require the colour temperature, with an optional brightness
+		if ( len( actionArguments ) < 1 || len( actionArguments ) > 2 ) {
+			exitWithErrorMessage( "Bulb temperature command requires an argument for the colour temperature in kelvin, then optionally the brightness percentage & transition." )
+		}
+		colourTemperature, colourTemperatureParseError := strconv.Atoi( actionArguments[ 0 ] )
+		if ( colourTemperatureParseError != nil || colourTemperature < kasa.MinColourTemperature || colourTemperature > kasa.MaxColourTemperature ) {
+			exitWithErrorMessage( fmt.Sprintf( "Invalid colour temperature, must be between %d and %d kelvin.", kasa.MinColourTemperature, kasa.MaxColourTemperature ) )
+		}
+		change := kasa.LightStateChange{ ColourTemperature: &colourTemperature, Transition: transition }
+		if ( len( actionArguments ) > 1 ) {
+			brightness := parsePercentage( actionArguments[ 1 ], "brightness" )
+			change.Brightness = &brightness
+		}
+
+		// Change the colour temperature
+		changeLightState( ctx, client, change )
+
+	// Is this to apply or save a preset?
+	} else if ( action == "preset" || action == "save" ) {
+
+		// Require the preset number
+		if ( len( actionArguments ) != 1 ) {
+			exitWithErrorMessage( fmt.Sprintf( "Bulb %s command requires an argument for the preset number.", action ) )
+		}
+
+		// Find the preset, by its number in the list
+		smartBulb, infoError := client.GetBulbInformation( ctx )
+		if ( infoError != nil ) {
+			exitWithErrorMessage( infoError.Error() )
+		}
+		number, numberParseError := strconv.Atoi( actionArguments[ 0 ] )
+		if ( numberParseError != nil || number < 1 || number > len( smartBulb.Presets ) ) {
+			exitWithErrorMessage( fmt.Sprintf( "Invalid preset number, must be between 1 and %d.", len( smartBulb.Presets ) ) )
+		}
+		preset := smartBulb.Presets[ number - 1 ]
+
+		// Switch the light to the preset
+		if ( action == "preset" ) {
+			_, applyError := client.ApplyLightPreset( ctx, preset, transition )
+			if ( applyError != nil ) {
+				exitWithErrorMessage( applyError.Error() )
+			}
+
+		// Save the current light in place of the preset
+		} else {
+			if ( transition > 0 ) {
+				exitWithErrorMessage( "Bulb save command does not accept a transition." )
+			}
+
+			saveError := client.SaveLightPreset( ctx, kasa.LightPreset{
+				Index: preset.Index,
+				Hue: smartBulb.Light.Hue,
+				Saturation: smartBulb.Light.Saturation,
+				ColourTemperature: smartBulb.Light.ColourTemperature,
+				Brightness: smartBulb.Light.Brightness,
+			} )
+			if ( saveError != nil ) {
+				exitWithErrorMessage( saveError.Error() )
+			}
+		}
+
+	// Require a valid action
+	} else {
+		exitWithErrorMessage( "Invalid bulb action, must be either 'show', 'on', 'off', 'brightness', 'colour', 'temperature', 'preset' or 'save'." )
+	}
+
+	// Display the light afterwards, so the change can be checked
+	displayBulb( ctx, client, outputFormat )
+
+}
+
+// Switches the light on & changes its state
+func changeLightState( ctx context.Context, client *kasa.Client, change kasa.LightStateChange ) {
+	powerState := true
+	change.PowerState = &powerState
+
+	_, setError := client.SetBulbState( ctx, change )
+	if ( setError != nil ) {
+		exitWithErrorMessage( setError.Error() )
+	}
+}
+
+// Separates the transition from the end of the arguments, if the last one is a duration with a unit (e.g., 500ms or 2s)
+func parseTransition( arguments []string ) ( []string, time.Duration ) {
+	if ( len( arguments ) == 0 ) {
+		return arguments, 0
+	}
+
+	// Plain numbers are values, not transitions
+	lastArgument := arguments[ len( arguments ) - 1 ]
+	_, numberParseError := strconv.Atoi( lastArgument )
+	if ( numberParseError == nil ) {
+		return arguments, 0
+	}
+
+	// Use the last argument as the transition if it is a duration
+	transition, durationParseError := time.ParseDuration( lastArgument )
+	if ( durationParseError != nil ) {
+		return arguments, 0
+	}
+	if ( transition < 0 ) {
+		exitWithErrorMessage( "Invalid transition, cannot be negative." )
+	}
+
+	return arguments[ : len( arguments ) - 1 ], transition
+}
+
+// Parses a whole percentage, exiting if it is not valid
+func parsePercentage( argument string, name string ) ( int ) {
+	percentage, parseError := strconv.Atoi( argument )
+	if ( parseError != nil || percentage < 0 || percentage > 100 ) {
+		exitWithErrorMessage( fmt.Sprintf( "Invalid %s, must be a whole percentage between 0 and 100.", name ) )
+	}
+
+	return percentage
+}
+
+// Displays the light & presets of a smart bulb
+func displayBulb( ctx context.Context, client *kasa.Client, outputFormat string ) {
+
+	// Fetch the system information, which includes the light & presets
+	smartBulb, infoError := client.GetBulbInformation( ctx )
+	if ( infoError != nil ) {
+		exitWithErrorMessage( infoError.Error() )
+	}
+
+	// Convert each preset
+	presets := make( []lightPresetReport, len( smartBulb.Presets ) )
+	for index, preset := range smartBulb.Presets {
+		presets[ index ] = lightPresetReport{
+			Number: index + 1,
+			Hue: preset.Hue,
+			Saturation: preset.Saturation,
+			ColourTemperature: preset.ColourTemperature,
+			Brightness: preset.Brightness,
+		}
+	}
+
+	// Display the light as JSON if requested
+	if ( outputFormat == "json" ) {
+		printJSON( bulbReport{
+			Alias: smartBulb.Alias,
+			Power: smartBulb.Light.PowerState,
+			Mode: smartBulb.Light.Mode,
+			Hue: smartBulb.Light.Hue,
+			Saturation: smartBulb.Light.Saturation,
+			ColourTemperature: smartBulb.Light.ColourTemperature,
+			Brightness: smartBulb.Light.Brightness,
+			Presets: presets,
+		} )
+		return
+	}
+
+	// Display the light, then each preset on its own line
+	fmt.Printf( "Alias: '%s'.\n", smartBulb.Alias )
+	fmt.Printf( "Power State: '%t'.\n", smartBulb.Light.PowerState )
+	fmt.Printf( "Light: '%s'.\n", formatLight( smartBulb.Light.Hue, smartBulb.Light.Saturation, smartBulb.Light.ColourTemperature, smartBulb.Light.Brightness ) )
+	for _, preset := range presets {
+		fmt.Printf( "Preset %d: '%s'.\n", preset.Number, formatLight( preset.Hue, preset.Saturation, preset.ColourTemperature, preset.Brightness ) )
+	}
+
+}
+
+// Formats a light state for humans, as white light when the colour temperature is set or colour otherwise
+func formatLight( hue int, saturation int, colourTemperature int, brightness int ) ( string ) {
+	if ( colourTemperature != 0 ) {
+		return fmt.Sprintf( "%dK at %d%%", colourTemperature, brightness )
+	}
+
+	return fmt.Sprintf( "hue %d°, saturation %d%% at %d%%", hue, saturation, brightness )
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/viral32111/kasa-smart-plug/source/kasa"
+)
+
+// Structure for a countdown rule, for the JSON output format
+type countdownRuleReport struct {
+	Identifier string `json:"id"`
+	Name string `json:"name"`
+	Enabled bool `json:"enabled"`
+	Delay int `json:"delay"` // seconds
+	Remaining int `json:"remaining"` // seconds
+	Power bool `json:"power"`
+}
+
+// Lists, sets or cancels the countdown
+func runCountdownCommand( ctx context.Context, client *kasa.Client, commandArguments []string, outputFormat string ) {
+
+	// Default to listing the rules
+	action := "list"
+	actionArguments := []string{}
+	if ( len( commandArguments ) > 0 ) {
+		action, actionArguments = commandArguments[ 0 ], commandArguments[ 1 : ]
+	}
+
+	// Is this to list the rules?
+	if ( action == "list" ) {
+
+		// Require no arguments
+		if ( len( actionArguments ) > 0 ) {
+			exitWithErrorMessage( "Countdown list command does not require any arguments." )
+		}
+
+	// Is this to start a countdown?
+	} else if ( action == "set" ) {
+
+		// Require the power state & delay, with an optional name
+		if ( len( actionArguments ) < 2 || len( actionArguments ) > 3 ) {
+			exitWithErrorMessage( "Countdown set command requires arguments for the power state & delay, then optionally the name." )
+		}
+
+		// Parse the power state
+		rule := kasa.CountdownRule{ Enabled: true }
+		if ( actionArguments[ 0 ] == "on" ) {
+			rule.PowerState = true
+		} else if ( actionArguments[ 0 ] == "off" ) {
+			rule.PowerState = false
+		} else {
+			exitWithErrorMessage( "Invalid power state, must be either 'on' or 'off'." )
+		}
+
+		// Parse the delay, as minutes if there is no unit
+		minutes, minutesParseError := strconv.Atoi( actionArguments[ 1 ] )
+		if ( minutesParseError == nil ) {
+			rule.Delay = time.Duration( minutes ) * time.Minute
+		} else {
+			delay, delayParseError := time.ParseDuration( actionArguments[ 1 ] )
+			if ( delayParseError != nil ) {
+				exitWithErrorMessage( fmt.Sprintf( "Invalid delay '%s', must be minutes or a duration such as 1h30m.", actionArguments[ 1 ] ) )
+			}
+
+			rule.Delay = delay
+		}
+
+		// Use the name
+		if ( len( actionArguments ) > 2 ) {
+			rule.Name = actionArguments[ 2 ]
+		}
+
+		// Replace any existing countdown, as smart plugs only hold one
+		deleteError := client.DeleteAllCountdownRules( ctx )
+		if ( deleteError != nil ) {
+			exitWithErrorMessage( deleteError.Error() )
+		}
+		_, addError := client.AddCountdownRule( ctx, rule )
+		if ( addError != nil ) {
+			exitWithErrorMessage( addError.Error() )
+		}
+
+	// Is this to cancel the countdown?
+	} else if ( action == "cancel" ) {
+
+		// Require no arguments
+		if ( len( actionArguments ) > 0 ) {
+			exitWithErrorMessage( "Countdown cancel command does not require any arguments." )
+		}
+
+		// Remove every countdown
+		deleteError := client.DeleteAllCountdownRules( ctx )
+		if ( deleteError != nil ) {
+			exitWithErrorMessage( deleteError.Error() )
+		}
+
+	// Require a valid action
+	} else {
+		exitWithErrorMessage( "Invalid countdown action, must be either 'list', 'set' or 'cancel'." )
+	}
+
+	// Display the rules afterwards, so the change can be checked
+	displayCountdown( ctx, client, outputFormat )
+
+}
+
+// Displays the countdown rules
+func displayCountdown( ctx context.Context, client *kasa.Client, outputFormat string ) {
+
+	// Fetch the rules
+	rules, rulesError := client.GetCountdownRules( ctx )
+	if ( rulesError != nil ) {
+		exitWithErrorMessage( rulesError.Error() )
+	}
+
+	// Convert each rule
+	reports := make( []countdownRuleReport, len( rules ) )
+	for index, rule := range rules {
+		reports[ index ] = countdownRuleReport{
+			Identifier: rule.Identifier,
+			Name: rule.Name,
+			Enabled: rule.Enabled,
+			Delay: int( rule.Delay / time.Second ),
+			Remaining: int( rule.Remaining / time.Second ),
+			Power: rule.PowerState,
+		}
+	}
+
+	// Display the rules as JSON if requested
+	if ( outputFormat == "json" ) {
+		printJSON( reports )
+		return
+	}
+
+	// Display each rule on its own line
+	if ( len( rules ) == 0 ) {
+		fmt.Println( "No countdown is set." )
+	}
+	for _, rule := range rules {
+		power := "off"
+		if ( rule.PowerState ) {
+			power = "on"
+		}
+
+		if ( rule.Enabled && rule.Remaining > 0 ) {
+			fmt.Printf( "%s\tturning %s in %s of %s\t'%s'\n", rule.Identifier, power, rule.Remaining, rule.Delay, rule.Name )
+		} else {
+			fmt.Printf( "%s\tfinished turning %s after %s\t'%s'\n", rule.Identifier, power, rule.Delay, rule.Name )
+		}
+	}
+
+}
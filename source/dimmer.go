@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/viral32111/kasa-smart-plug/source/kasa"
+)
+
+// Structure for the state of a dimmer switch, for the JSON output format
+type dimmerReport struct {
+	Alias string `json:"alias"`
+	Power bool `json:"power"`
+	Brightness int `json:"brightness"` // percent
+	MinimumThreshold int `json:"minimum_threshold"` // percent
+	FadeOnTime int `json:"fade_on_time"` // milliseconds
+	FadeOffTime int `json:"fade_off_time"` // milliseconds
+	GentleOnTime int `json:"gentle_on_time"` // milliseconds
+	GentleOffTime int `json:"gentle_off_time"` // milliseconds
+	RampRate int `json:"ramp_rate"`
+	DoubleClick string `json:"double_click"`
+	LongPress string `json:"long_press"`
+}
+
+// The names of the button actions in the command-line arguments, for each mode
+var dimmerActionNames = map[string]string {
+	"none": kasa.DimmerActionNone,
+	"instant": kasa.DimmerActionInstant,
+	"gentle": kasa.DimmerActionGentle,
+	"preset": kasa.DimmerActionPreset,
+}
+
+// Shows or changes the brightness, fade times or button actions of a dimmer switch
+func runDimmerCommand( ctx context.Context, client *kasa.Client, commandArguments []string, outputFormat string ) {
+
+	// Default to showing the dimmer switch
+	action := "show"
+	actionArguments := []string{}
+	if ( len( commandArguments ) > 0 ) {
+		action, actionArguments = commandArguments[ 0 ], commandArguments[ 1 : ]
+	}
+
+	// Is this to show the dimmer switch?
+	if ( action == "show" ) {
+
+		// Require no arguments
+		if ( len( actionArguments ) > 0 ) {
+			exitWithErrorMessage( "Dimmer show command does not require any arguments." )
+		}
+
+	// Is this to change the brightness?
+	} else if ( action == "brightness" ) {
+
+		// Require the brightness, with an optional transition
+		brightnessArguments, transition := parseTransition( actionArguments )
+		if ( len( brightnessArguments ) != 1 ) {
+			exitWithErrorMessage( "Dimmer brightness command requires an argument for the brightness percentage, then optionally the transition." )
+		}
+		brightness := parsePercentage( brightnessArguments[ 0 ], "brightness" )
+		if ( brightness < 1 ) {
+			exitWithErrorMessage( "Invalid brightness, must be at least 1%, use the power command to switch off." )
+		}
+
+		// Fade to the brightness if there is a transition, which also switches the dimmer switch on
+		var setError error
+		if ( transition > 0 ) {
+			setError = client.FadeBrightness( ctx, brightness, transition )
+		} else {
+			setError = client.SetBrightness( ctx, brightness )
+		}
+		if ( setError != nil ) {
+			exitWithErrorMessage( setError.Error() )
+		}
+
+	// Is this to change one of the fade times?
+	} else if ( action == "fade-on" || action == "fade-off" || action == "gentle-on" || action == "gentle-off" ) {
+
+		// Require the duration
+		if ( len( actionArguments ) != 1 ) {
+			exitWithErrorMessage( fmt.Sprintf( "Dimmer %s command requires an argument for the duration (e.g., 1s or 500ms).", action ) )
+		}
+		duration, durationParseError := time.ParseDuration( actionArguments[ 0 ] )
+		if ( durationParseError != nil || duration < 0 ) {
+			exitWithErrorMessage( fmt.Sprintf( "Invalid duration '%s', must be a duration such as 1s or 500ms.", actionArguments[ 0 ] ) )
+		}
+
+		// Set the fade time
+		var setError error
+		switch ( action ) {
+			case "fade-on":
+				setError = client.SetFadeOnTime( ctx, duration )
+			case "fade-off":
+				setError = client.SetFadeOffTime( ctx, duration )
+			case "gentle-on":
+				setError = client.SetGentleOnTime( ctx, duration )
+			case "gentle-off":
+				setError = client.SetGentleOffTime( ctx, duration )
+		}
+		if ( setError != nil ) {
+			exitWithErrorMessage( setError.Error() )
+		}
+
+	// Is this to change what the button does?
+	} else if ( action == "double-click" || action == "long-press" ) {
+
+		// Require the mode, with the preset number for the preset mode
+		if ( len( actionArguments ) < 1 || len( actionArguments ) > 2 ) {
+			exitWithErrorMessage( fmt.Sprintf( "Dimmer %s command requires an argument for the action, either 'none', 'instant', 'gentle' or 'preset' followed by the preset number.", action ) )
+		}
+		mode, isKnownMode := dimmerActionNames[ actionArguments[ 0 ] ]
+		if ( !isKnownMode ) {
+			exitWithErrorMessage( "Invalid dimmer action, must be either 'none', 'instant', 'gentle' or 'preset'." )
+		}
+		buttonAction := kasa.DimmerAction{ Mode: mode }
+		if ( mode == kasa.DimmerActionPreset ) {
+			if ( len( actionArguments ) != 2 ) {
+				exitWithErrorMessage( "Dimmer preset action requires an argument for the preset number." )
+			}
+			number, numberParseError := strconv.Atoi( actionArguments[ 1 ] )
+			if ( numberParseError != nil || number < 1 ) {
+				exitWithErrorMessage( "Invalid preset number, must be a whole number starting from 1." )
+			}
+			buttonAction.PresetIndex = number - 1
+		} else if ( len( actionArguments ) > 1 ) {
+			exitWithErrorMessage( "Only the preset action accepts a preset number." )
+		}
+
+		// Set the button action
+		var setError error
+		if ( action == "double-click" ) {
+			setError = client.SetDoubleClickAction( ctx, buttonAction )
+		} else {
+			setError = client.SetLongPressAction( ctx, buttonAction )
+		}
+		if ( setError != nil ) {
+			exitWithErrorMessage( setError.Error() )
+		}
+
+	// Require a valid action
+	} else {
+		exitWithErrorMessage( "Invalid dimmer action, must be either 'show', 'brightness', 'fade-on', 'fade-off', 'gentle-on', 'gentle-off', 'double-click' or 'long-press'." )
+	}
+
+	// Display the dimmer switch afterwards, so the change can be checked
+	displayDimmer( ctx, client, outputFormat )
+
+}
+
+// Displays the brightness, fade times & button actions of a dimmer switch
+func displayDimmer( ctx context.Context, client *kasa.Client, outputFormat string ) {
+
+	// Fetch the properties
+	smartDimmer, propertiesError := client.GetDimmerProperties( ctx )
+	if ( propertiesError != nil ) {
+		exitWithErrorMessage( propertiesError.Error() )
+	}
+
+	// Display the dimmer switch as JSON if requested
+	if ( outputFormat == "json" ) {
+		printJSON( dimmerReport{
+			Alias: smartDimmer.Alias,
+			Power: smartDimmer.PowerState,
+			Brightness: smartDimmer.Brightness,
+			MinimumThreshold: smartDimmer.Parameters.MinimumThreshold,
+			FadeOnTime: int( smartDimmer.Parameters.FadeOnTime / time.Millisecond ),
+			FadeOffTime: int( smartDimmer.Parameters.FadeOffTime / time.Millisecond ),
+			GentleOnTime: int( smartDimmer.Parameters.GentleOnTime / time.Millisecond ),
+			GentleOffTime: int( smartDimmer.Parameters.GentleOffTime / time.Millisecond ),
+			RampRate: smartDimmer.Parameters.RampRate,
+			DoubleClick: formatDimmerAction( smartDimmer.DoubleClick ),
+			LongPress: formatDimmerAction( smartDimmer.LongPress ),
+		} )
+		return
+	}
+
+	// Display each property on its own line
+	fmt.Printf( "Alias: '%s'.\n", smartDimmer.Alias )
+	fmt.Printf( "Power State: '%t'.\n", smartDimmer.PowerState )
+	fmt.Printf( "Brightness: '%d%%'.\n", smartDimmer.Brightness )
+	fmt.Printf( "Minimum Threshold: '%d%%'.\n", smartDimmer.Parameters.MinimumThreshold )
+	fmt.Printf( "Fade On Time: '%s'.\n", smartDimmer.Parameters.FadeOnTime )
+	fmt.Printf( "Fade Off Time: '%s'.\n", smartDimmer.Parameters.FadeOffTime )
+	fmt.Printf( "Gentle On Time: '%s'.\n", smartDimmer.Parameters.GentleOnTime )
+	fmt.Printf( "Gentle Off Time: '%s'.\n", smartDimmer.Parameters.GentleOffTime )
+	fmt.Printf( "Ramp Rate: '%d'.\n", smartDimmer.Parameters.RampRate )
+	fmt.Printf( "Double Click: '%s'.\n", formatDimmerAction( smartDimmer.DoubleClick ) )
+	fmt.Printf( "Long Press: '%s'.\n", formatDimmerAction( smartDimmer.LongPress ) )
+
+}
+
+// Formats a button action with the same names as the command-line arguments
+func formatDimmerAction( buttonAction kasa.DimmerAction ) ( string ) {
+	for name, mode := range dimmerActionNames {
+		if ( mode == buttonAction.Mode && mode == kasa.DimmerActionPreset ) {
+			return fmt.Sprintf( "%s %d", name, buttonAction.PresetIndex + 1 )
+		} else if ( mode == buttonAction.Mode ) {
+			return name
+		}
+	}
+
+	return buttonAction.Mode
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/viral32111/kasa-smart-plug/source/kasa"
+)
+
+// Scans the local network & lists every smart plug that responded
+func runDiscoverCommand( ctx context.Context, discovery *kasa.Discovery, commandArguments []string, outputFormat string ) {
+
+	// Require no arguments
+	if ( len( commandArguments ) > 0 ) {
+		exitWithErrorMessage( "Discover command does not require any arguments." )
+	}
+
+	// Scan the local network
+	devices, discoverError := discovery.Run( ctx )
+	if ( discoverError != nil ) {
+		exitWithErrorMessage( discoverError.Error() )
+	}
+
+	// Display the devices as JSON if requested
+	if ( outputFormat == "json" ) {
+		printJSON( devices )
+		return
+	}
+
+	// Display each device on its own line
+	if ( len( devices ) == 0 ) {
+		fmt.Println( "No smart plugs found." )
+	}
+	for _, device := range devices {
+		fmt.Printf( "%s\t%s\t%s\t%s\t'%s'\n", device.Address, device.MACAddress, device.Model, device.DeviceIdentifier, device.Alias )
+	}
+
+}
+
+// Scans the local network for a single smart plug, exiting if there is not exactly one
+func discoverSmartPlug( ctx context.Context, discovery *kasa.Discovery ) ( net.IP ) {
+
+	// Scan the local network
+	devices, discoverError := discovery.Run( ctx )
+	if ( discoverError != nil ) {
+		exitWithErrorMessage( discoverError.Error() )
+	}
+
+	// Fail if no smart plugs responded
+	if ( len( devices ) == 0 ) {
+		exitWithErrorMessage( "No smart plugs found on the local network, set the IPv4 address using the -address flag." )
+	}
+
+	// Fail if there is more than one smart plug to choose from
+	if ( len( devices ) > 1 ) {
+		addresses := make( []string, len( devices ) )
+		for index, device := range devices {
+			addresses[ index ] = fmt.Sprintf( "%s ('%s')", device.Address, device.Alias )
+		}
+
+		exitWithErrorMessage( fmt.Sprintf( "Found %d smart plugs on the local network (%s), choose one using the -address flag.", len( devices ), strings.Join( addresses, ", " ) ) )
+	}
+
+	// Use the only smart plug that responded
+	return devices[ 0 ].Address
+
+}
+
+// Scans the local network for every smart plug, exiting if there are none
+func discoverSmartPlugs( ctx context.Context, discovery *kasa.Discovery ) ( []net.IP ) {
+
+	// Scan the local network
+	devices, discoverError := discovery.Run( ctx )
+	if ( discoverError != nil ) {
+		exitWithErrorMessage( discoverError.Error() )
+	}
+
+	// Fail if no smart plugs responded
+	if ( len( devices ) == 0 ) {
+		exitWithErrorMessage( "No smart plugs found on the local network, set the IPv4 addresses using the -address flag." )
+	}
+
+	// Use every smart plug that responded
+	addresses := make( []net.IP, len( devices ) )
+	for index, device := range devices {
+		addresses[ index ] = device.Address
+	}
+
+	return addresses
+
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/viral32111/kasa-smart-plug/source/kasa"
+)
+
+// The number of real-time readings to average when calibrating, & the time to wait between them
+const (
+	calibrationSampleCount = 5
+	calibrationSampleInterval = time.Second
+)
+
+// Structure for the calibration of the energy meter, for the JSON output format
+type calibrationReport struct {
+	VoltageGain int `json:"vgain"`
+	CurrentGain int `json:"igain"`
+}
+
+// Erases the energy usage history, or shows, sets or guides calibration of the energy meter
+func runEmeterCommand( ctx context.Context, client *kasa.Client, commandArguments []string, outputFormat string, isConfirmed bool ) {
+
+	// Require an action
+	if ( len( commandArguments ) == 0 ) {
+		exitWithErrorMessage( "Energy meter command requires an action, either 'erase', 'gains' or 'calibrate'." )
+	}
+	action, actionArguments := commandArguments[ 0 ], commandArguments[ 1 : ]
+
+	// Is this to erase the energy usage history?
+	if ( action == "erase" ) {
+
+		// Require no arguments
+		if ( len( actionArguments ) > 0 ) {
+			exitWithErrorMessage( "Energy meter erase command does not require any arguments." )
+		}
+
+		// Require explicit confirmation, as this cannot be undone
+		if ( !isConfirmed ) {
+			exitWithErrorMessage( "Erasing the energy usage history cannot be undone, use the -yes flag to confirm." )
+		}
+
+		// Erase the history
+		eraseError := client.EraseEnergyUsage( ctx )
+		if ( eraseError != nil ) {
+			exitWithErrorMessage( eraseError.Error() )
+		}
+
+		if ( outputFormat == "human" ) {
+			fmt.Println( "Erased the energy usage history." )
+		}
+
+	// Is this to show or set the calibration?
+	} else if ( action == "gains" ) {
+
+		// Set the gains if they are provided
+		if ( len( actionArguments ) == 2 ) {
+			voltageGain, voltageParseError := strconv.Atoi( actionArguments[ 0 ] )
+			currentGain, currentParseError := strconv.Atoi( actionArguments[ 1 ] )
+			if ( voltageParseError != nil || currentParseError != nil ) {
+				exitWithErrorMessage( "Invalid gains, must be whole numbers." )
+			}
+
+			setError := client.SetCalibration( ctx, kasa.Calibration{ VoltageGain: voltageGain, CurrentGain: currentGain } )
+			if ( setError != nil ) {
+				exitWithErrorMessage( setError.Error() )
+			}
+
+		// Otherwise, require no arguments
+		} else if ( len( actionArguments ) != 0 ) {
+			exitWithErrorMessage( "Energy meter gains command requires either no arguments, or 2 arguments for the voltage & current gains." )
+		}
+
+		// Display the gains
+		calibration, calibrationError := client.GetCalibration( ctx )
+		if ( calibrationError != nil ) {
+			exitWithErrorMessage( calibrationError.Error() )
+		}
+
+		displayCalibration( calibration, outputFormat )
+
+	// Is this to guide calibration?
+	} else if ( action == "calibrate" ) {
+		runCalibration( ctx, client, actionArguments, outputFormat, isConfirmed )
+
+	// Require a valid action
+	} else {
+		exitWithErrorMessage( "Invalid energy meter action, must be either 'erase', 'gains' or 'calibrate'." )
+	}
+
+}
+
+// Guides calibration against a reference voltage & current, asking for them if they are not provided
+func runCalibration( ctx context.Context, client *kasa.Client, actionArguments []string, outputFormat string, isConfirmed bool ) {
+
+	// Require at most the reference voltage & current
+	if ( len( actionArguments ) > 2 ) {
+		exitWithErrorMessage( "Energy meter calibrate command accepts at most 2 arguments for the reference voltage & current." )
+	}
+
+	// Use the reference voltage & current provided, or ask for them
+	references := [ 2 ]float64{}
+	questions := [ 2 ]string{
+		"Voltage measured with a reference meter, in volts (0 to skip): ",
+		"Current measured with a reference meter, in amps (0 to skip): ",
+	}
+	for index := range references {
+		value := ""
+		if ( index < len( actionArguments ) ) {
+			value = actionArguments[ index ]
+		} else {
+			value = askForInput( questions[ index ] )
+		}
+
+		reference, parseError := strconv.ParseFloat( value, 64 )
+		if ( parseError != nil || reference < 0 ) {
+			exitWithErrorMessage( fmt.Sprintf( "Invalid reference value '%s', must be a positive number.", value ) )
+		}
+
+		references[ index ] = reference
+	}
+	if ( references[ 0 ] == 0 && references[ 1 ] == 0 ) {
+		exitWithErrorMessage( "Nothing to calibrate, provide a reference voltage and/or current." )
+	}
+
+	// Fetch the current gains
+	calibration, calibrationError := client.GetCalibration( ctx )
+	if ( calibrationError != nil ) {
+		exitWithErrorMessage( calibrationError.Error() )
+	}
+
+	// Average a few readings, as they fluctuate with the load
+	if ( outputFormat == "human" ) {
+		fmt.Printf( "Measuring for %d seconds, keep the load steady...\n", calibrationSampleCount * int( calibrationSampleInterval / time.Second ) )
+	}
+	measured := kasa.EnergyUsage{}
+	for sample := 0; sample < calibrationSampleCount; sample++ {
+		if ( sample > 0 ) {
+			time.Sleep( calibrationSampleInterval )
+		}
+
+		energyUsage, energyUsageError := client.GetEnergyUsage( ctx )
+		if ( energyUsageError != nil ) {
+			exitWithErrorMessage( energyUsageError.Error() )
+		}
+
+		measured.Voltage += energyUsage.Voltage / calibrationSampleCount
+		measured.Amperage += energyUsage.Amperage / calibrationSampleCount
+	}
+
+	// Work out the new gains
+	calibrated, calibrateError := calibration.Calibrate( measured, references[ 0 ], references[ 1 ] )
+	if ( calibrateError != nil ) {
+		exitWithErrorMessage( calibrateError.Error() )
+	}
+
+	// Show what will change & ask before applying it
+	if ( outputFormat == "human" ) {
+		fmt.Printf( "Voltage: measured %.2f V, reference %.2f V, gain %d -> %d.\n", measured.Voltage, references[ 0 ], calibration.VoltageGain, calibrated.VoltageGain )
+		fmt.Printf( "Current: measured %.3f A, reference %.3f A, gain %d -> %d.\n", measured.Amperage, references[ 1 ], calibration.CurrentGain, calibrated.CurrentGain )
+	}
+	if ( !isConfirmed && !askForConfirmation( "Apply the new gains?" ) ) {
+		exitWithErrorMessage( "Calibration cancelled, the gains have not been changed." )
+	}
+
+	// Apply the new gains
+	setError := client.SetCalibration( ctx, calibrated )
+	if ( setError != nil ) {
+		exitWithErrorMessage( setError.Error() )
+	}
+
+	displayCalibration( calibrated, outputFormat )
+
+}
+
+// Displays the calibration of the energy meter
+func displayCalibration( calibration kasa.Calibration, outputFormat string ) {
+	if ( outputFormat == "json" ) {
+		printJSON( calibrationReport{ VoltageGain: calibration.VoltageGain, CurrentGain: calibration.CurrentGain } )
+		return
+	}
+
+	fmt.Printf( "Voltage Gain: %d.\n", calibration.VoltageGain )
+	fmt.Printf( "Current Gain: %d.\n", calibration.CurrentGain )
+}
@@ -0,0 +1,145 @@
+package emulator
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/viral32111/kasa-smart-plug/source/kasa"
+)
+
+// Error codes returned by the smart plug
+const (
+	errorCodeModuleNotSupported = -1
+	errorCodeMethodNotSupported = -2
+	errorCodeInvalidArgument = -3
+)
+
+// Answers a decrypted JSON query with a JSON response, in the same way as a real smart plug
+func ( plug *Plug ) Answer( queryBytes []byte ) ( []byte ) {
+
+	// Parse the query into its modules, answering nothing if it is not valid
+	var query map[string]json.RawMessage
+	decodeError := json.Unmarshal( queryBytes, &query )
+	if ( decodeError != nil ) {
+		return []byte( "{}" )
+	}
+
+	// Parse the outlets the query is addressed to, as power strips expect them alongside the modules
+	var queryContext struct {
+		ChildIdentifiers []string `json:"child_ids"`
+	}
+	contextBytes, hasContext := query[ "context" ]
+	delete( query, "context" )
+	if ( hasContext && json.Unmarshal( contextBytes, &queryContext ) != nil ) {
+		return []byte( "{}" )
+	}
+
+	// Parse each module into its methods, answering nothing if any are not valid
+	modules := make( map[string]map[string]json.RawMessage, len( query ) )
+	for moduleName, methodsBytes := range query {
+		var methods map[string]json.RawMessage
+		if ( json.Unmarshal( methodsBytes, &methods ) != nil ) {
+			return []byte( "{}" )
+		}
+
+		modules[ moduleName ] = methods
+	}
+
+	// Guard the state & handlers for the entire query
+	plug.mutex.Lock()
+	defer plug.mutex.Unlock()
+
+	// Catch up on anything that should have happened since the last query
+	plug.state.advanceCountdowns( time.Now() )
+
+	// Answer each method within each module
+	response := map[string]any{}
+	for moduleName, methods := range modules {
+
+		// Reply with an error if the module is not supported
+		moduleHandlers, isModuleSupported := plug.handlers[ moduleName ]
+		if ( !isModuleSupported ) {
+			response[ moduleName ] = errorResult( errorCodeModuleNotSupported, "module not support" )
+			continue
+		}
+
+		// Answer each method
+		moduleResponse := map[string]any{}
+		for methodName, arguments := range methods {
+
+			// Reply with an error if the method is not supported
+			handler, isMethodSupported := moduleHandlers[ methodName ]
+			if ( !isMethodSupported ) {
+				moduleResponse[ methodName ] = errorResult( errorCodeMethodNotSupported, "method not support" )
+				continue
+			}
+
+			// Answer for each outlet instead, if the query is addressed to outlets & the method applies to them
+			var result map[string]any
+			var handlerError error
+			childHandler, isChildMethod := plug.childHandlers[ moduleName ][ methodName ]
+			if ( len( queryContext.ChildIdentifiers ) > 0 && isChildMethod ) {
+				for _, childIdentifier := range queryContext.ChildIdentifiers {
+					child := plug.state.child( childIdentifier )
+					if ( child == nil ) {
+						result, handlerError = nil, errors.New( "invalid child id" )
+						break
+					}
+
+					result, handlerError = childHandler( &plug.state, child, arguments )
+					if ( handlerError != nil ) {
+						break
+					}
+				}
+			} else {
+				result, handlerError = handler( &plug.state, arguments )
+			}
+
+			// Reply with the code of a device error if the handler failed with one, like for modules that are only supported on outlets
+			var deviceError *kasa.DeviceError
+			if ( errors.As( handlerError, &deviceError ) ) {
+				moduleResponse[ methodName ] = errorResult( deviceError.Code, deviceError.Message )
+				continue
+			}
+
+			// Otherwise, reply with an invalid argument error if the handler failed
+			if ( handlerError != nil ) {
+				moduleResponse[ methodName ] = errorResult( errorCodeInvalidArgument, handlerError.Error() )
+				continue
+			}
+
+			// Otherwise, reply with the result & a successful error code
+			if ( result == nil ) {
+				result = map[string]any{}
+			}
+			result[ "err_code" ] = 0
+			moduleResponse[ methodName ] = result
+
+		}
+		response[ moduleName ] = moduleResponse
+
+	}
+
+	// Encode the response as JSON, which cannot fail for these types
+	responseBytes, _ := json.Marshal( response )
+	return responseBytes
+
+}
+
+// Creates the result for a failed method
+func errorResult( errorCode int, errorMessage string ) ( map[string]any ) {
+	return map[string]any{
+		"err_code": errorCode,
+		"err_msg": errorMessage,
+	}
+}
+
+// Parses the JSON arguments for a method into the given value, treating missing arguments as empty
+func parseArguments( arguments json.RawMessage, value any ) ( error ) {
+	if ( len( arguments ) == 0 || string( arguments ) == "null" ) {
+		return nil
+	}
+
+	return json.Unmarshal( arguments, value )
+}
@@ -0,0 +1,200 @@
+package emulator
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/viral32111/kasa-smart-plug/source/kasa"
+)
+
+// The module that smart bulbs use for the light
+const lightingServiceModule = "smartlife.iot.smartbulb.lightingservice"
+
+// Creates a fake smart bulb with the default state of a KL130
+func NewBulb() ( *Plug ) {
+
+	// Create the smart bulb with the default state
+	plug := &Plug{
+		InitialKey: kasa.DefaultInitialKey,
+		state: DefaultBulbState(),
+		handlers: map[string]map[string]Handler{},
+		childHandlers: map[string]map[string]ChildHandler{},
+	}
+
+	// Register the handlers for the supported modules, without the relay, indicator light or energy meter of a smart plug
+	plug.registerSystemHandlers()
+	plug.registerTimeHandlers()
+	plug.registerNetworkHandlers()
+	plug.registerBulbHandlers()
+	delete( plug.handlers[ "system" ], "set_relay_state" )
+	delete( plug.handlers[ "system" ], "set_led_off" )
+
+	// Return the smart bulb
+	return plug
+
+}
+
+// Returns the default state, resembling a KL130 that is switched on to warm white
+func DefaultBulbState() ( State ) {
+	state := DefaultState()
+	state.Alias = "Emulated Smart Bulb"
+	state.DeviceName = "Smart Wi-Fi LED Bulb with Color Changing"
+	state.Model = "KL130(EU)"
+	state.Features = ""
+	state.Type = "IOT.SMARTBULB"
+	state.Light = &kasa.LightState{
+		PowerState: true,
+		Mode: "normal",
+		ColourTemperature: 2700,
+		Brightness: 50,
+	}
+	state.LightPresets = []kasa.LightPreset{
+		{ Index: 0, ColourTemperature: 2700, Brightness: 50 },
+		{ Index: 1, ColourTemperature: 6500, Brightness: 100 },
+		{ Index: 2, Hue: 0, Saturation: 100, Brightness: 75 },
+		{ Index: 3, Hue: 240, Saturation: 100, Brightness: 25 },
+	}
+
+	return state
+}
+
+// Registers the handlers for the lighting service module & the system information of a smart bulb
+func ( plug *Plug ) registerBulbHandlers() {
+
+	// Returns the system information, which is shaped differently to that of a smart plug
+	plug.Handle( "system", "get_sysinfo", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+		presets := []map[string]any{}
+		for _, preset := range state.LightPresets {
+			presets = append( presets, map[string]any{
+				"index": preset.Index,
+				"hue": preset.Hue,
+				"saturation": preset.Saturation,
+				"color_temp": preset.ColourTemperature,
+				"brightness": preset.Brightness,
+			} )
+		}
+
+		return map[string]any{
+			"sw_ver": state.SoftwareVersion,
+			"hw_ver": state.HardwareVersion,
+			"model": state.Model,
+			"description": state.DeviceName,
+			"alias": state.Alias,
+			"mic_type": state.Type,
+			"dev_state": "normal",
+			"mic_mac": state.MACAddress,
+			"deviceId": state.DeviceIdentifier,
+			"oemId": state.OEMIdentifier,
+			"hwId": state.HardwareIdentifier,
+			"is_factory": false,
+			"rssi": state.SignalStrength,
+			"is_dimmable": 1,
+			"is_color": 1,
+			"is_variable_color_temp": 1,
+			"light_state": lightStateResult( state.Light ),
+			"preferred_state": presets,
+			"active_mode": state.activeMode(),
+		}, nil
+	} )
+
+	// Returns the state of the light
+	plug.Handle( lightingServiceModule, "get_light_state", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+		return lightStateResult( state.Light ), nil
+	} )
+
+	// Changes the state of the light straight away, as there is nothing to see fading
+	plug.Handle( lightingServiceModule, "transition_light_state", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+
+		// Parse the arguments
+		var parameters struct {
+			PowerState *int `json:"on_off"`
+			Hue *int `json:"hue"`
+			Saturation *int `json:"saturation"`
+			ColourTemperature *int `json:"color_temp"`
+			Brightness *int `json:"brightness"`
+			Transition *int `json:"transition_period"`
+		}
+		parseError := parseArguments( arguments, &parameters )
+		if ( parseError != nil || !isInRange( parameters.Hue, 0, 360 ) || !isInRange( parameters.Saturation, 0, 100 ) || !isInRange( parameters.Brightness, 0, 100 ) || !isInRange( parameters.Transition, 0, 10000000 ) ) {
+			return nil, errors.New( "invalid argument" )
+		}
+		if ( parameters.ColourTemperature != nil && *parameters.ColourTemperature != 0 && !isInRange( parameters.ColourTemperature, kasa.MinColourTemperature, kasa.MaxColourTemperature ) ) {
+			return nil, errors.New( "invalid argument" )
+		}
+
+		// Change only what was given
+		if ( parameters.PowerState != nil ) {
+			state.Light.PowerState = ( *parameters.PowerState != 0 )
+		}
+		if ( parameters.Hue != nil ) {
+			state.Light.Hue = *parameters.Hue
+		}
+		if ( parameters.Saturation != nil ) {
+			state.Light.Saturation = *parameters.Saturation
+		}
+		if ( parameters.ColourTemperature != nil ) {
+			state.Light.ColourTemperature = *parameters.ColourTemperature
+		}
+		if ( parameters.Brightness != nil ) {
+			state.Light.Brightness = *parameters.Brightness
+		}
+
+		return lightStateResult( state.Light ), nil
+
+	} )
+
+	// Saves a light state in place of the preset at the same index
+	plug.Handle( lightingServiceModule, "set_preferred_state", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+
+		// Parse the arguments
+		var parameters struct {
+			Index *int `json:"index"`
+			Hue int `json:"hue"`
+			Saturation int `json:"saturation"`
+			ColourTemperature int `json:"color_temp"`
+			Brightness int `json:"brightness"`
+		}
+		parseError := parseArguments( arguments, &parameters )
+		if ( parseError != nil || parameters.Index == nil || !isInRange( parameters.Index, 0, len( state.LightPresets ) - 1 ) ) {
+			return nil, errors.New( "invalid argument" )
+		}
+
+		state.LightPresets[ *parameters.Index ] = kasa.LightPreset{
+			Index: *parameters.Index,
+			Hue: parameters.Hue,
+			Saturation: parameters.Saturation,
+			ColourTemperature: parameters.ColourTemperature,
+			Brightness: parameters.Brightness,
+		}
+
+		return nil, nil
+
+	} )
+
+}
+
+// Creates the light state result, which holds the state it turns on with while it is off
+func lightStateResult( light *kasa.LightState ) ( map[string]any ) {
+	lightState := map[string]any{
+		"mode": light.Mode,
+		"hue": light.Hue,
+		"saturation": light.Saturation,
+		"color_temp": light.ColourTemperature,
+		"brightness": light.Brightness,
+	}
+
+	if ( !light.PowerState ) {
+		return map[string]any{
+			"on_off": 0,
+			"dft_on_state": lightState,
+		}
+	}
+
+	lightState[ "on_off" ] = 1
+	return lightState
+}
+
+// Checks an optional argument is within range, treating a missing argument as valid
+func isInRange( value *int, minimum int, maximum int ) ( bool ) {
+	return value == nil || ( *value >= minimum && *value <= maximum )
+}
@@ -0,0 +1,37 @@
+package emulator
+
+import (
+	"time"
+)
+
+// Updates the time left on each enabled countdown rule, switching the power relay & disabling the rule once it reaches zero
+func ( state *State ) advanceCountdowns( now time.Time ) {
+	for _, rule := range state.Countdown.Rules {
+
+		// Skip rules that are not counting down
+		enable, _ := rule[ "enable" ].( float64 )
+		delay, _ := rule[ "delay" ].( float64 )
+		if ( enable == 0 || !state.Countdown.Enabled ) {
+			rule[ "remain" ] = 0
+			continue
+		}
+
+		// Update the time left, counting from when the rule was added or last edited
+		remaining := time.Duration( delay ) * time.Second - now.Sub( state.Countdown.updatedAt[ rule[ "id" ] ] )
+		if ( remaining > 0 ) {
+			rule[ "remain" ] = int( remaining.Round( time.Second ) / time.Second )
+			continue
+		}
+
+		// Switch the power relay once the countdown is over, restarting the uptime if it is being switched on
+		action, _ := rule[ "act" ].( float64 )
+		relayState := ( action != 0 )
+		if ( relayState && !state.RelayState ) {
+			state.PoweredOnAt = now
+		}
+		state.RelayState = relayState
+		rule[ "enable" ] = float64( 0 )
+		rule[ "remain" ] = 0
+
+	}
+}
@@ -0,0 +1,205 @@
+package emulator
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/viral32111/kasa-smart-plug/source/kasa"
+)
+
+// The module that dimmer switches use for the brightness
+const dimmerModule = "smartlife.iot.dimmer"
+
+// Structure for holding the mutable state of a fake dimmer switch
+type DimmerState struct {
+	Brightness int // percent
+	Parameters kasa.DimmerParameters
+	DoubleClick kasa.DimmerAction
+	LongPress kasa.DimmerAction
+}
+
+// Creates a fake dimmer switch with the default state of a HS220
+func NewDimmer() ( *Plug ) {
+
+	// Create the dimmer switch with the default state
+	plug := &Plug{
+		InitialKey: kasa.DefaultInitialKey,
+		state: DefaultDimmerState(),
+		handlers: map[string]map[string]Handler{},
+		childHandlers: map[string]map[string]ChildHandler{},
+	}
+
+	// Register the handlers for the supported modules, as a smart plug without an energy meter
+	plug.registerSystemHandlers()
+	plug.registerTimeHandlers()
+	plug.registerNetworkHandlers()
+	plug.registerRuleHandlers( "schedule", func( state *State ) *RuleSet { return &state.Schedule } )
+	plug.registerRuleHandlers( "count_down", func( state *State ) *RuleSet { return &state.Countdown } )
+	plug.registerRuleHandlers( "anti_theft", func( state *State ) *RuleSet { return &state.AntiTheft } )
+	plug.registerDimmerHandlers()
+
+	// Return the dimmer switch
+	return plug
+
+}
+
+// Returns the default state, resembling a HS220 that is switched on at half brightness
+func DefaultDimmerState() ( State ) {
+	state := DefaultState()
+	state.Alias = "Emulated Dimmer Switch"
+	state.DeviceName = "Smart Wi-Fi Dimmer"
+	state.Model = "HS220(US)"
+	state.Features = "TIM"
+	state.Dimmer = &DimmerState{
+		Brightness: 50,
+		Parameters: kasa.DimmerParameters{
+			FadeOnTime: time.Second,
+			FadeOffTime: time.Second,
+			GentleOnTime: 3 * time.Second,
+			GentleOffTime: 10 * time.Second,
+			RampRate: 30,
+		},
+		DoubleClick: kasa.DimmerAction{ Mode: kasa.DimmerActionGentle },
+		LongPress: kasa.DimmerAction{ Mode: kasa.DimmerActionInstant },
+	}
+
+	return state
+}
+
+// Registers the handlers for the dimmer module
+func ( plug *Plug ) registerDimmerHandlers() {
+
+	// Sets the brightness straight away
+	plug.Handle( dimmerModule, "set_brightness", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+
+		// Parse the arguments
+		var parameters struct {
+			Brightness *int `json:"brightness"`
+		}
+		parseError := parseArguments( arguments, &parameters )
+		if ( parseError != nil || parameters.Brightness == nil || !isInRange( parameters.Brightness, 1, 100 ) ) {
+			return nil, errors.New( "invalid argument" )
+		}
+
+		state.Dimmer.Brightness = *parameters.Brightness
+
+		return nil, nil
+
+	} )
+
+	// Fades to the brightness straight away, as there is nothing to see fading, switching the relay on
+	plug.Handle( dimmerModule, "set_dimmer_transition", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+
+		// Parse the arguments
+		var parameters struct {
+			Brightness *int `json:"brightness"`
+			Duration *int `json:"duration"`
+		}
+		parseError := parseArguments( arguments, &parameters )
+		if ( parseError != nil || parameters.Brightness == nil || parameters.Duration == nil || !isInRange( parameters.Brightness, 1, 100 ) || *parameters.Duration < 0 ) {
+			return nil, errors.New( "invalid argument" )
+		}
+
+		// Restart the uptime if the relay is being switched on
+		if ( !state.RelayState ) {
+			state.PoweredOnAt = time.Now()
+		}
+		state.RelayState = true
+		state.Dimmer.Brightness = *parameters.Brightness
+
+		return nil, nil
+
+	} )
+
+	// Returns how the light is faded, with times in milliseconds
+	plug.Handle( dimmerModule, "get_dimmer_parameters", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+		return map[string]any{
+			"minThreshold": state.Dimmer.Parameters.MinimumThreshold,
+			"fadeOnTime": state.Dimmer.Parameters.FadeOnTime.Milliseconds(),
+			"fadeOffTime": state.Dimmer.Parameters.FadeOffTime.Milliseconds(),
+			"gentleOnTime": state.Dimmer.Parameters.GentleOnTime.Milliseconds(),
+			"gentleOffTime": state.Dimmer.Parameters.GentleOffTime.Milliseconds(),
+			"rampRate": state.Dimmer.Parameters.RampRate,
+			"bulb_type": 1,
+		}, nil
+	} )
+
+	// Sets each of the fade times, which are in milliseconds
+	plug.handleDimmerTime( "set_fade_on_time", "fadeTime", func( state *State ) *time.Duration { return &state.Dimmer.Parameters.FadeOnTime } )
+	plug.handleDimmerTime( "set_fade_off_time", "fadeTime", func( state *State ) *time.Duration { return &state.Dimmer.Parameters.FadeOffTime } )
+	plug.handleDimmerTime( "set_gentle_on_time", "duration", func( state *State ) *time.Duration { return &state.Dimmer.Parameters.GentleOnTime } )
+	plug.handleDimmerTime( "set_gentle_off_time", "duration", func( state *State ) *time.Duration { return &state.Dimmer.Parameters.GentleOffTime } )
+
+	// Returns what the button does, & what happens when power is restored
+	plug.Handle( dimmerModule, "get_default_behavior", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+		return map[string]any{
+			"double_click": dimmerActionResult( state.Dimmer.DoubleClick ),
+			"long_press": dimmerActionResult( state.Dimmer.LongPress ),
+			"hard_on": map[string]any{ "mode": "last_status" },
+			"soft_on": map[string]any{ "mode": "last_status" },
+		}, nil
+	} )
+
+	// Sets what the button does when double-clicked & long-pressed
+	plug.handleDimmerAction( "set_double_click_action", func( state *State ) *kasa.DimmerAction { return &state.Dimmer.DoubleClick } )
+	plug.handleDimmerAction( "set_long_press_action", func( state *State ) *kasa.DimmerAction { return &state.Dimmer.LongPress } )
+
+}
+
+// Adds the handler for a method that sets one of the fade times
+func ( plug *Plug ) handleDimmerTime( methodName string, argumentName string, fadeTime func( state *State ) *time.Duration ) {
+	plug.Handle( dimmerModule, methodName, func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+
+		// Parse the arguments
+		var parameters map[string]int
+		parseError := parseArguments( arguments, &parameters )
+		milliseconds, exists := parameters[ argumentName ]
+		if ( parseError != nil || !exists || milliseconds < 0 ) {
+			return nil, errors.New( "invalid argument" )
+		}
+
+		*fadeTime( state ) = time.Duration( milliseconds ) * time.Millisecond
+
+		return nil, nil
+
+	} )
+}
+
+// Adds the handler for a method that sets what the button does
+func ( plug *Plug ) handleDimmerAction( methodName string, buttonAction func( state *State ) *kasa.DimmerAction ) {
+	plug.Handle( dimmerModule, methodName, func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+
+		// Parse the arguments
+		var parameters struct {
+			Mode string `json:"mode"`
+			Index *int `json:"index"`
+		}
+		parseError := parseArguments( arguments, &parameters )
+		if ( parseError != nil || ( parameters.Mode != kasa.DimmerActionNone && parameters.Mode != kasa.DimmerActionInstant && parameters.Mode != kasa.DimmerActionGentle && parameters.Mode != kasa.DimmerActionPreset ) ) {
+			return nil, errors.New( "invalid argument" )
+		}
+		if ( parameters.Mode == kasa.DimmerActionPreset && ( parameters.Index == nil || !isInRange( parameters.Index, 0, 3 ) ) ) {
+			return nil, errors.New( "invalid argument" )
+		}
+
+		// Set the action, only keeping the preset for the customise preset mode
+		action := kasa.DimmerAction{ Mode: parameters.Mode }
+		if ( parameters.Index != nil && parameters.Mode == kasa.DimmerActionPreset ) {
+			action.PresetIndex = *parameters.Index
+		}
+		*buttonAction( state ) = action
+
+		return nil, nil
+
+	} )
+}
+
+// Creates the result for what the button does, which only has a preset for the customise preset mode
+func dimmerActionResult( action kasa.DimmerAction ) ( map[string]any ) {
+	if ( action.Mode == kasa.DimmerActionPreset ) {
+		return map[string]any{ "mode": action.Mode, "index": action.PresetIndex }
+	}
+
+	return map[string]any{ "mode": action.Mode }
+}
@@ -0,0 +1,191 @@
+package emulator
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// Registers the handlers for the energy meter module
+func ( plug *Plug ) registerEnergyMeterHandlers() {
+
+	// Returns the real-time energy usage, which is zero while the relay is off
+	plug.Handle( "emeter", "get_realtime", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+		return state.realtimeEnergy( state.RelayState, state.Current, state.Voltage, state.Power, state.TotalEnergy ), nil
+	} )
+
+	// Returns the real-time energy usage of an outlet, which is zero while its relay is off
+	plug.HandleChild( "emeter", "get_realtime", func( state *State, child *ChildState, arguments json.RawMessage ) ( map[string]any, error ) {
+		return state.realtimeEnergy( child.RelayState, child.Current, child.Voltage, child.Power, child.TotalEnergy ), nil
+	} )
+
+	// Returns the energy used on each recorded day of a month
+	plug.Handle( "emeter", "get_daystat", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+
+		// Parse the arguments
+		var parameters struct {
+			Year *int `json:"year"`
+			Month *int `json:"month"`
+		}
+		parseError := parseArguments( arguments, &parameters )
+		if ( parseError != nil || parameters.Year == nil || parameters.Month == nil || *parameters.Month < 1 || *parameters.Month > 12 ) {
+			return nil, errors.New( "invalid argument" )
+		}
+
+		// Include each recorded day in the month, in order
+		days := []map[string]any{}
+		for _, date := range sortedDates( state.DailyEnergy ) {
+			if ( date.Year() == *parameters.Year && int( date.Month() ) == *parameters.Month ) {
+				day := map[string]any{
+					"year": date.Year(),
+					"month": int( date.Month() ),
+					"day": date.Day(),
+				}
+				setEnergy( day, state.DailyEnergy[ date.Format( time.DateOnly ) ], state.LegacyEnergyMeter )
+				days = append( days, day )
+			}
+		}
+
+		return map[string]any{
+			"day_list": days,
+		}, nil
+
+	} )
+
+	// Returns the energy used in each recorded month of a year
+	plug.Handle( "emeter", "get_monthstat", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+
+		// Parse the arguments
+		var parameters struct {
+			Year *int `json:"year"`
+		}
+		parseError := parseArguments( arguments, &parameters )
+		if ( parseError != nil || parameters.Year == nil ) {
+			return nil, errors.New( "invalid argument" )
+		}
+
+		// Add up the recorded days in each month of the year, in order
+		months := []map[string]any{}
+		monthTotals := []int{}
+		for _, date := range sortedDates( state.DailyEnergy ) {
+			if ( date.Year() != *parameters.Year ) {
+				continue
+			}
+
+			if ( len( months ) == 0 || months[ len( months ) - 1 ][ "month" ] != int( date.Month() ) ) {
+				months = append( months, map[string]any{
+					"year": date.Year(),
+					"month": int( date.Month() ),
+				} )
+				monthTotals = append( monthTotals, 0 )
+			}
+
+			monthTotals[ len( monthTotals ) - 1 ] += state.DailyEnergy[ date.Format( time.DateOnly ) ]
+		}
+		for index, month := range months {
+			setEnergy( month, monthTotals[ index ], state.LegacyEnergyMeter )
+		}
+
+		return map[string]any{
+			"month_list": months,
+		}, nil
+
+	} )
+
+	// Erases the recorded energy usage
+	plug.Handle( "emeter", "erase_emeter_stat", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+		state.DailyEnergy = map[string]int{}
+		state.TotalEnergy = 0
+
+		return nil, nil
+	} )
+
+	// Returns the calibration of the energy meter
+	plug.Handle( "emeter", "get_vgain_igain", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+		return map[string]any{
+			"vgain": state.VoltageGain,
+			"igain": state.CurrentGain,
+		}, nil
+	} )
+
+	// Sets the calibration of the energy meter
+	plug.Handle( "emeter", "set_vgain_igain", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+
+		// Parse the arguments
+		var parameters struct {
+			VoltageGain *int `json:"vgain"`
+			CurrentGain *int `json:"igain"`
+		}
+		parseError := parseArguments( arguments, &parameters )
+		if ( parseError != nil || parameters.VoltageGain == nil || parameters.CurrentGain == nil || *parameters.VoltageGain <= 0 || *parameters.CurrentGain <= 0 ) {
+			return nil, errors.New( "invalid argument" )
+		}
+
+		state.VoltageGain = *parameters.VoltageGain
+		state.CurrentGain = *parameters.CurrentGain
+
+		return nil, nil
+
+	} )
+
+}
+
+// Returns the real-time energy usage result for the given readings, skewed by the calibration & in the field names of the firmware
+func ( state *State ) realtimeEnergy( relayState bool, current float64, voltage float64, power float64, totalEnergy float64 ) ( map[string]any ) {
+	if ( !relayState ) {
+		current, power = 0, 0
+	}
+
+	// Skew the readings by the calibration
+	voltageScale := float64( state.VoltageGain ) / DefaultVoltageGain
+	currentScale := float64( state.CurrentGain ) / DefaultCurrentGain
+	voltage *= voltageScale
+	current *= currentScale
+	power *= voltageScale * currentScale
+
+	// Older firmware uses floating-point base units
+	if ( state.LegacyEnergyMeter ) {
+		return map[string]any{
+			"current": current,
+			"voltage": voltage,
+			"power": power,
+			"total": totalEnergy,
+		}
+	}
+
+	return map[string]any{
+		"current_ma": int( math.Round( current * 1000.0 ) ),
+		"voltage_mv": int( math.Round( voltage * 1000.0 ) ),
+		"power_mw": int( math.Round( power * 1000.0 ) ),
+		"total_wh": int( math.Round( totalEnergy * 1000.0 ) ),
+	}
+}
+
+// Sets the energy of a day or month statistic, in kilowatt-hours for older firmware or watthours otherwise
+func setEnergy( statistic map[string]any, watthours int, isLegacy bool ) {
+	if ( isLegacy ) {
+		statistic[ "energy" ] = float64( watthours ) / 1000.0
+	} else {
+		statistic[ "energy_wh" ] = watthours
+	}
+}
+
+// Returns the dates of the recorded energy usage, oldest first
+func sortedDates( dailyEnergy map[string]int ) ( []time.Time ) {
+	dates := make( []time.Time, 0, len( dailyEnergy ) )
+
+	for key := range dailyEnergy {
+		date, parseError := time.Parse( time.DateOnly, key )
+		if ( parseError == nil ) {
+			dates = append( dates, date )
+		}
+	}
+
+	sort.Slice( dates, func( a, b int ) bool {
+		return dates[ a ].Before( dates[ b ] )
+	} )
+
+	return dates
+}
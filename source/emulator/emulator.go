@@ -0,0 +1,320 @@
+// Package emulator is an in-process fake smart plug, for testing the client & command-line utility without any hardware.
+package emulator
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/viral32111/kasa-smart-plug/source/kasa"
+)
+
+// Structure for holding a fake smart plug & its listeners
+type Plug struct {
+
+	// The initial key for encrypting & decrypting data
+	InitialKey int
+
+	// The mutable state of the smart plug, guarded by the mutex
+	mutex sync.Mutex
+	state State
+
+	// The handlers for each method, keyed by module name then method name
+	handlers map[string]map[string]Handler
+
+	// The handlers for methods that apply to a single outlet of a power strip, used in place of the above when a query is addressed to outlets
+	childHandlers map[string]map[string]ChildHandler
+
+	// The underlying listeners, once listening
+	tcpListener net.Listener
+	udpConnection net.PacketConn
+
+	// Closed once the smart plug stops listening, to close any open connections
+	closedChannel chan struct{}
+
+	// Tracks the goroutines serving connections, so closing can wait for them
+	waitGroup sync.WaitGroup
+
+	// The open TCP connections, guarded separately so they can be dropped from within a handler
+	connectionsMutex sync.Mutex
+	connections map[net.Conn]struct{}
+
+	// Ensures the listeners are only closed once, keeping the result for later calls
+	closeOnce sync.Once
+	closeError error
+
+}
+
+// Function that answers a single method, given the state & the JSON arguments, failing with a *kasa.DeviceError to reply with its error code
+type Handler func( state *State, arguments json.RawMessage ) ( map[string]any, error )
+
+// Function that answers a single method for an outlet of a power strip, given the state, the outlet & the JSON arguments
+type ChildHandler func( state *State, child *ChildState, arguments json.RawMessage ) ( map[string]any, error )
+
+// Creates a fake smart plug with the default state of a KP115, use Update with DefaultStripState to emulate a power strip instead
+func New() ( *Plug ) {
+
+	// Create the smart plug with the default state
+	plug := &Plug{
+		InitialKey: kasa.DefaultInitialKey,
+		state: DefaultState(),
+		handlers: map[string]map[string]Handler{},
+		childHandlers: map[string]map[string]ChildHandler{},
+	}
+
+	// Register the handlers for the supported modules
+	plug.registerSystemHandlers()
+	plug.registerTimeHandlers()
+	plug.registerEnergyMeterHandlers()
+	plug.registerNetworkHandlers()
+	plug.registerRuleHandlers( "schedule", func( state *State ) *RuleSet { return &state.Schedule } )
+	plug.registerRuleHandlers( "count_down", func( state *State ) *RuleSet { return &state.Countdown } )
+	plug.registerRuleHandlers( "anti_theft", func( state *State ) *RuleSet { return &state.AntiTheft } )
+
+	// Return the smart plug
+	return plug
+
+}
+
+// Adds or replaces the handler for a method
+func ( plug *Plug ) Handle( moduleName string, methodName string, handler Handler ) {
+
+	// Guard the handlers as connections may be in progress
+	plug.mutex.Lock()
+	defer plug.mutex.Unlock()
+
+	// Create the module if it does not exist yet
+	if ( plug.handlers[ moduleName ] == nil ) {
+		plug.handlers[ moduleName ] = map[string]Handler{}
+	}
+
+	// Set the handler
+	plug.handlers[ moduleName ][ methodName ] = handler
+
+}
+
+// Adds or replaces the handler for a method when a query is addressed to outlets of a power strip
+func ( plug *Plug ) HandleChild( moduleName string, methodName string, handler ChildHandler ) {
+
+	// Guard the handlers as connections may be in progress
+	plug.mutex.Lock()
+	defer plug.mutex.Unlock()
+
+	// Create the module if it does not exist yet
+	if ( plug.childHandlers[ moduleName ] == nil ) {
+		plug.childHandlers[ moduleName ] = map[string]ChildHandler{}
+	}
+
+	// Set the handler
+	plug.childHandlers[ moduleName ][ methodName ] = handler
+
+}
+
+// Removes every handler for a module, so it is answered as not supported like on models without it
+func ( plug *Plug ) RemoveModule( moduleName string ) {
+	plug.mutex.Lock()
+	defer plug.mutex.Unlock()
+
+	delete( plug.handlers, moduleName )
+	delete( plug.childHandlers, moduleName )
+}
+
+// Returns a copy of the current state, which is safe to read & modify while the smart plug is in use
+func ( plug *Plug ) State() ( State ) {
+	plug.mutex.Lock()
+	defer plug.mutex.Unlock()
+
+	return plug.state.clone()
+}
+
+// Modifies the current state
+func ( plug *Plug ) Update( modify func( state *State ) ) {
+	plug.mutex.Lock()
+	defer plug.mutex.Unlock()
+
+	modify( &plug.state )
+}
+
+// Starts listening for queries over TCP & UDP on the given address (e.g., 127.0.0.1:9999 or 127.0.0.1:0)
+func ( plug *Plug ) Listen( address string ) ( error ) {
+
+	// Try to start listening over TCP
+	tcpListener, tcpListenError := net.Listen( "tcp4", address )
+	if ( tcpListenError != nil ) {
+		return tcpListenError
+	}
+
+	// Try to start listening over UDP on the same port, in case a random port was chosen
+	tcpAddress := tcpListener.Addr().( *net.TCPAddr )
+	udpConnection, udpListenError := net.ListenPacket( "udp4", net.JoinHostPort( tcpAddress.IP.String(), strconv.Itoa( tcpAddress.Port ) ) )
+	if ( udpListenError != nil ) {
+		tcpListener.Close()
+		return udpListenError
+	}
+
+	// Set the listeners on the smart plug
+	plug.tcpListener = tcpListener
+	plug.udpConnection = udpConnection
+	plug.closedChannel = make( chan struct{} )
+
+	// Serve both listeners in the background
+	plug.waitGroup.Add( 2 )
+	go plug.serveTCP()
+	go plug.serveUDP()
+
+	// Return no error
+	return nil
+
+}
+
+// Returns the IP address the smart plug is listening on, or nil if it is not listening yet
+func ( plug *Plug ) Address() ( net.IP ) {
+	if ( plug.tcpListener == nil ) {
+		return nil
+	}
+
+	return plug.tcpListener.Addr().( *net.TCPAddr ).IP
+}
+
+// Returns the port number the smart plug is listening on, or zero if it is not listening yet
+func ( plug *Plug ) Port() ( int ) {
+	if ( plug.tcpListener == nil ) {
+		return 0
+	}
+
+	return plug.tcpListener.Addr().( *net.TCPAddr ).Port
+}
+
+// Stops listening & waits for all connections to finish, doing nothing if it never listened or is already closed
+func ( plug *Plug ) Close() ( error ) {
+
+	// Nothing to close if the smart plug never started listening
+	if ( plug.tcpListener == nil ) {
+		return nil
+	}
+
+	// Only close the listeners the first time, as closing the channel again would panic
+	plug.closeOnce.Do( func() {
+
+		// Stop accepting anything new & close the open connections
+		tcpCloseError := plug.tcpListener.Close()
+		udpCloseError := plug.udpConnection.Close()
+		close( plug.closedChannel )
+
+		// Wait for the open connections to finish
+		plug.waitGroup.Wait()
+
+		// Keep any errors from closing
+		plug.closeError = errors.Join( tcpCloseError, udpCloseError )
+
+	} )
+
+	// Return any errors from closing
+	return plug.closeError
+
+}
+
+// Closes every open TCP connection without answering, like a real smart plug dropping idle connections or restarting.
+// This is safe to call from within a handler, to drop the connection part-way through a query.
+func ( plug *Plug ) DropConnections() {
+	plug.connectionsMutex.Lock()
+	defer plug.connectionsMutex.Unlock()
+
+	for connection := range plug.connections {
+		connection.Close()
+	}
+}
+
+// Accepts TCP connections until the listener is closed
+func ( plug *Plug ) serveTCP() {
+	defer plug.waitGroup.Done()
+
+	for {
+
+		// Wait for the next connection, stopping once the listener is closed
+		connection, acceptError := plug.tcpListener.Accept()
+		if ( acceptError != nil ) {
+			return
+		}
+
+		// Serve the connection in the background
+		plug.waitGroup.Add( 1 )
+		go plug.serveConnection( connection )
+
+	}
+}
+
+// Answers length-prefixed queries on a TCP connection until it is closed
+func ( plug *Plug ) serveConnection( connection net.Conn ) {
+	defer plug.waitGroup.Done()
+	defer connection.Close()
+
+	// Track the connection so it can be dropped
+	plug.connectionsMutex.Lock()
+	if ( plug.connections == nil ) {
+		plug.connections = map[net.Conn]struct{}{}
+	}
+	plug.connections[ connection ] = struct{}{}
+	plug.connectionsMutex.Unlock()
+	defer func() {
+		plug.connectionsMutex.Lock()
+		delete( plug.connections, connection )
+		plug.connectionsMutex.Unlock()
+	}()
+
+	// Close the connection when the smart plug is closed
+	stopWatching := make( chan struct{} )
+	defer close( stopWatching )
+	go func() {
+		select {
+			case <-stopWatching:
+			case <-plug.closedChannel:
+				connection.Close()
+		}
+	}()
+
+	// Wrap the connection for encryption & framing
+	frameConnection := kasa.NewConn( connection, plug.InitialKey )
+
+	// Reuse the same buffer for every query
+	var queryBuffer []byte
+
+	for {
+
+		// Read the next query
+		queryBytes, readError := frameConnection.ReadFrame( queryBuffer )
+		if ( readError != nil ) {
+			return
+		}
+		queryBuffer = queryBytes
+
+		// Answer the query
+		writeError := frameConnection.WriteFrame( plug.Answer( queryBytes ) )
+		if ( writeError != nil ) {
+			return
+		}
+
+	}
+}
+
+// Answers unframed queries over UDP until the connection is closed, like discovery broadcasts
+func ( plug *Plug ) serveUDP() {
+	defer plug.waitGroup.Done()
+
+	queryBytes := make( []byte, 65535 )
+	for {
+
+		// Wait for the next query, stopping once the connection is closed
+		queryLength, senderAddress, readError := plug.udpConnection.ReadFrom( queryBytes )
+		if ( readError != nil ) {
+			return
+		}
+
+		// Answer the query
+		responseBytes := plug.Answer( kasa.DecryptData( queryBytes[ : queryLength ], plug.InitialKey ) )
+		plug.udpConnection.WriteTo( kasa.EncryptData( responseBytes, plug.InitialKey ), senderAddress )
+
+	}
+}
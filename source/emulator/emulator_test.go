@@ -0,0 +1,138 @@
+package emulator_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/viral32111/kasa-smart-plug/source/emulator"
+	"github.com/viral32111/kasa-smart-plug/source/kasa"
+)
+
+// Starts a fake smart plug on a random port & returns a client for it, both closed when the test finishes
+func newPlugClient( t *testing.T ) ( *emulator.Plug, *kasa.Client ) {
+	plug := emulator.New()
+
+	listenError := plug.Listen( "127.0.0.1:0" )
+	if ( listenError != nil ) {
+		t.Fatal( listenError )
+	}
+	t.Cleanup( func() { plug.Close() } )
+
+	client := kasa.NewClient( plug.Address(), plug.Port() )
+	t.Cleanup( func() { client.Close() } )
+
+	return plug, client
+}
+
+// The client must read the state of the fake smart plug, & changes made by the client must show in its state
+func TestClientAgainstPlug( t *testing.T ) {
+	plug, client := newPlugClient( t )
+	ctx := context.Background()
+
+	// Read everything about the smart plug
+	smartPlug, propertiesError := client.GetProperties( ctx )
+	if ( propertiesError != nil ) {
+		t.Fatal( propertiesError )
+	}
+	state := plug.State()
+	if ( smartPlug.Alias != state.Alias || smartPlug.DeviceModel != state.Model || smartPlug.PowerState != state.RelayState ) {
+		t.Errorf( "expected alias '%s', model '%s' & power %t, got '%s', '%s' & %t", state.Alias, state.Model, state.RelayState, smartPlug.Alias, smartPlug.DeviceModel, smartPlug.PowerState )
+	}
+	if ( smartPlug.Energy.Wattage != state.Power || smartPlug.Energy.Voltage != state.Voltage ) {
+		t.Errorf( "expected %.2f W at %.2f V, got %.2f W at %.2f V", state.Power, state.Voltage, smartPlug.Energy.Wattage, smartPlug.Energy.Voltage )
+	}
+	if ( !smartPlug.Capabilities.Has( kasa.CapabilityEnergyMeter ) || !smartPlug.Capabilities.Has( kasa.CapabilityTimer ) ) {
+		t.Errorf( "expected energy monitoring & timers, got %v", smartPlug.Capabilities.List() )
+	}
+
+	// Switch off & rename the smart plug
+	powerError := client.SetPowerState( ctx, false )
+	if ( powerError != nil ) {
+		t.Fatal( powerError )
+	}
+	aliasError := client.SetAlias( ctx, "Renamed" )
+	if ( aliasError != nil ) {
+		t.Fatal( aliasError )
+	}
+	state = plug.State()
+	if ( state.RelayState || state.Alias != "Renamed" ) {
+		t.Errorf( "expected power off & alias 'Renamed', got %t & '%s'", state.RelayState, state.Alias )
+	}
+
+	// Add a countdown rule & read it back
+	identifier, addError := client.AddCountdownRule( ctx, kasa.CountdownRule{ Name: "Test", Enabled: true, Delay: time.Minute, PowerState: true } )
+	if ( addError != nil ) {
+		t.Fatal( addError )
+	}
+	rules, rulesError := client.GetCountdownRules( ctx )
+	if ( rulesError != nil ) {
+		t.Fatal( rulesError )
+	}
+	if ( len( rules ) != 1 || rules[ 0 ].Identifier != identifier || rules[ 0 ].Delay != time.Minute || !rules[ 0 ].PowerState ) {
+		t.Errorf( "expected the countdown rule '%s', got %+v", identifier, rules )
+	}
+}
+
+// Modifying a copy of the state must not change the fake smart plug
+func TestStateIsCopied( t *testing.T ) {
+	plug := emulator.New()
+	plug.Update( func( state *emulator.State ) {
+		*state = emulator.DefaultStripState( 2 )
+		state.Schedule.Rules = []map[string]any{ { "id": "1", "name": "Original", "args": []any{ "kept" } } }
+	} )
+
+	// Change everything that is shared by reference
+	copiedState := plug.State()
+	copiedState.Schedule.Rules[ 0 ][ "name" ] = "Changed"
+	copiedState.Schedule.Rules[ 0 ][ "args" ].( []any )[ 0 ] = "changed"
+	copiedState.Children[ 0 ].Alias = "Changed"
+	copiedState.AccessPoints[ 0 ].SSID = "Changed"
+	for date := range copiedState.DailyEnergy {
+		copiedState.DailyEnergy[ date ] = -1
+	}
+
+	// Check the fake smart plug kept the original values
+	state := plug.State()
+	if ( state.Schedule.Rules[ 0 ][ "name" ] != "Original" || state.Schedule.Rules[ 0 ][ "args" ].( []any )[ 0 ] != "kept" ) {
+		t.Errorf( "schedule rule was changed through a copy: %v", state.Schedule.Rules[ 0 ] )
+	}
+	if ( state.Children[ 0 ].Alias != "Outlet 1" ) {
+		t.Errorf( "outlet alias was changed through a copy: '%s'", state.Children[ 0 ].Alias )
+	}
+	if ( state.AccessPoints[ 0 ].SSID != "Home Network" ) {
+		t.Errorf( "access point was changed through a copy: '%s'", state.AccessPoints[ 0 ].SSID )
+	}
+	for date, energy := range state.DailyEnergy {
+		if ( energy < 0 ) {
+			t.Errorf( "daily energy for %s was changed through a copy", date )
+		}
+	}
+
+	// Check the light of a smart bulb is copied too
+	bulb := emulator.NewBulb()
+	copiedBulbState := bulb.State()
+	copiedBulbState.Light.Brightness = -1
+	if ( bulb.State().Light.Brightness < 0 ) {
+		t.Error( "light state was changed through a copy" )
+	}
+}
+
+// Closing must do nothing before listening, & closing twice must not panic
+func TestCloseIsIdempotent( t *testing.T ) {
+	plug := emulator.New()
+	if ( plug.Address() != nil || plug.Port() != 0 ) {
+		t.Errorf( "expected no address before listening, got %v:%d", plug.Address(), plug.Port() )
+	}
+	if ( plug.Close() != nil ) {
+		t.Error( "expected no error closing before listening" )
+	}
+
+	listenError := plug.Listen( "127.0.0.1:0" )
+	if ( listenError != nil ) {
+		t.Fatal( listenError )
+	}
+	if ( plug.Close() != nil || plug.Close() != nil ) {
+		t.Error( "expected no error closing twice" )
+	}
+}
@@ -0,0 +1,100 @@
+// Runs a fake smart plug until interrupted, for trying the command-line utility without any hardware.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+
+	"github.com/viral32111/kasa-smart-plug/source/emulator"
+)
+
+// Entry-point
+func main() {
+
+	// Values of the command-line flags, and the defaults
+	flagAddress := "127.0.0.1:9999"
+	flagAlias := ""
+	flagInitialKey := 171
+	flagLegacyEnergyMeter := false
+	flagOutlets := 0
+	flagBulb := false
+	flagDimmer := false
+	flagModel := ""
+	flagFeatures := ""
+
+	// Setup & parse the command-line flags
+	flag.StringVar( &flagAddress, "address", flagAddress, "The IPv4 address & port number to listen on for TCP & UDP." )
+	flag.StringVar( &flagAlias, "alias", flagAlias, "The initial alias of the fake smart plug, instead of the default for a smart plug or power strip." )
+	flag.IntVar( &flagInitialKey, "initial-key", flagInitialKey, "The initial value for the XOR encryption." )
+	flag.BoolVar( &flagLegacyEnergyMeter, "legacy-emeter", flagLegacyEnergyMeter, "Respond with the energy meter field names of older firmware, such as the HS110 v1." )
+	flag.IntVar( &flagOutlets, "outlets", flagOutlets, "Emulate a power strip with this many outlets, such as 6 for the HS300 or 3 for the KP303." )
+	flag.BoolVar( &flagBulb, "bulb", flagBulb, "Emulate a smart bulb, such as the KL130, instead of a smart plug." )
+	flag.BoolVar( &flagDimmer, "dimmer", flagDimmer, "Emulate a dimmer switch, such as the HS220, instead of a smart plug." )
+	flag.StringVar( &flagModel, "model", flagModel, "The model to report, instead of the default for a smart plug, power strip, smart bulb or dimmer switch." )
+	flag.StringVar( &flagFeatures, "features", flagFeatures, "The feature string to report, such as TIM for a HS100 without an energy meter, which also removes the energy meter if ENE is not in it." )
+	flag.Parse()
+
+	// Require a sensible number of outlets
+	if ( flagOutlets < 0 || flagOutlets > 99 ) {
+		fmt.Fprintln( os.Stderr, "Invalid number of outlets, must be between 0 and 99." )
+		os.Exit( 1 )
+	}
+
+	// Require at most one of a power strip, smart bulb or dimmer switch, as nothing is more than one
+	if ( ( flagOutlets > 0 && flagBulb ) || ( flagOutlets > 0 && flagDimmer ) || ( flagBulb && flagDimmer ) ) {
+		fmt.Fprintln( os.Stderr, "Cannot emulate more than one of a power strip, smart bulb or dimmer switch." )
+		os.Exit( 1 )
+	}
+
+	// Create the fake smart plug, smart bulb or dimmer switch
+	plug := emulator.New()
+	if ( flagBulb ) {
+		plug = emulator.NewBulb()
+	} else if ( flagDimmer ) {
+		plug = emulator.NewDimmer()
+	}
+	plug.InitialKey = flagInitialKey
+	plug.Update( func( state *emulator.State ) {
+		if ( flagOutlets > 0 ) {
+			*state = emulator.DefaultStripState( flagOutlets )
+		}
+
+		if ( flagAlias != "" ) {
+			state.Alias = flagAlias
+		}
+
+		if ( flagModel != "" ) {
+			state.Model = flagModel
+		}
+
+		if ( flagFeatures != "" ) {
+			state.Features = flagFeatures
+		}
+
+		state.LegacyEnergyMeter = flagLegacyEnergyMeter
+	} )
+
+	// Answer as not supported for the energy meter if the features do not include it
+	if ( flagFeatures != "" && !slices.Contains( strings.Split( flagFeatures, ":" ), "ENE" ) ) {
+		plug.RemoveModule( "emeter" )
+	}
+
+	// Start listening
+	listenError := plug.Listen( flagAddress )
+	if ( listenError != nil ) {
+		fmt.Fprintln( os.Stderr, listenError.Error() )
+		os.Exit( 1 )
+	}
+	fmt.Printf( "Emulating a %s on %s:%d, press Ctrl+C to stop.\n", plug.State().Model, plug.Address(), plug.Port() )
+
+	// Wait until interrupted, then stop listening
+	interruptChannel := make( chan os.Signal, 1 )
+	signal.Notify( interruptChannel, os.Interrupt )
+	<-interruptChannel
+	plug.Close()
+
+}
@@ -0,0 +1,57 @@
+package emulator
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/viral32111/kasa-smart-plug/source/kasa"
+)
+
+// Registers the handlers for the network interface module
+func ( plug *Plug ) registerNetworkHandlers() {
+
+	// Returns the wireless networks in range
+	plug.Handle( "netif", "get_scaninfo", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+		accessPoints := state.AccessPoints
+		if ( accessPoints == nil ) {
+			accessPoints = []kasa.AccessPoint{}
+		}
+
+		return map[string]any{
+			"ap_list": accessPoints,
+		}, nil
+	} )
+
+	// Joins a wireless network, which must be in range & use the given security type
+	plug.Handle( "netif", "set_stainfo", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+
+		// Parse the arguments
+		var parameters struct {
+			SSID string `json:"ssid"`
+			Password string `json:"password"`
+			KeyType *kasa.KeyType `json:"key_type"`
+		}
+		parseError := parseArguments( arguments, &parameters )
+		if ( parseError != nil || parameters.SSID == "" || parameters.KeyType == nil ) {
+			return nil, errors.New( "invalid argument" )
+		}
+
+		// Fail unless the network is in range with the same security type, & there is a password if it is secured
+		for _, accessPoint := range state.AccessPoints {
+			if ( accessPoint.SSID != parameters.SSID ) {
+				continue
+			}
+
+			if ( accessPoint.KeyType != *parameters.KeyType || ( accessPoint.KeyType != kasa.KeyTypeNone && parameters.Password == "" ) ) {
+				return nil, errors.New( "invalid argument" )
+			}
+
+			state.StationSSID = parameters.SSID
+			return nil, nil
+		}
+
+		return nil, errors.New( "ssid not found" )
+
+	} )
+
+}
@@ -0,0 +1,219 @@
+package emulator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+)
+
+// Structure for holding the rules of the schedule, count_down or anti_theft modules
+type RuleSet struct {
+	Enabled bool
+	Rules []map[string]any
+
+	// The maximum number of rules, or zero for no limit
+	Limit int
+
+	// Counter for assigning identifiers to new rules
+	nextIdentifier int
+
+	// When each rule was last added or edited, keyed by identifier, for counting down
+	updatedAt map[any]time.Time
+}
+
+// Registers the rule management handlers for a module, given a function that returns its rules from the state
+func ( plug *Plug ) registerRuleHandlers( moduleName string, ruleSet func( state *State ) *RuleSet ) {
+
+	// Returns every rule & whether they are enabled overall
+	plug.Handle( moduleName, "get_rules", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+		rules := ruleSet( state )
+
+		ruleList := rules.Rules
+		if ( ruleList == nil ) {
+			ruleList = []map[string]any{}
+		}
+
+		return map[string]any{
+			"rule_list": ruleList,
+			"enable": boolToInt( rules.Enabled ),
+			"version": 2,
+		}, nil
+	} )
+
+	// Adds a rule with a new identifier
+	plug.Handle( moduleName, "add_rule", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+		rules := ruleSet( state )
+
+		// Parse the arguments as the rule
+		rule := map[string]any{}
+		parseError := parseArguments( arguments, &rule )
+		if ( parseError != nil ) {
+			return nil, errors.New( "invalid argument" )
+		}
+
+		// Fail if there is no space for another rule
+		if ( rules.Limit > 0 && len( rules.Rules ) >= rules.Limit ) {
+			return nil, errors.New( "table is full" )
+		}
+
+		// Assign an identifier, like the 32 hexadecimal characters used by real smart plugs
+		rules.nextIdentifier++
+		identifier := fmt.Sprintf( "%032X", rules.nextIdentifier )
+		rule[ "id" ] = identifier
+		rules.Rules = append( rules.Rules, rule )
+		rules.touch( identifier )
+
+		return map[string]any{
+			"id": identifier,
+		}, nil
+	} )
+
+	// Replaces the rule with the same identifier
+	plug.Handle( moduleName, "edit_rule", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+		rules := ruleSet( state )
+
+		// Parse the arguments as the rule
+		rule := map[string]any{}
+		parseError := parseArguments( arguments, &rule )
+		if ( parseError != nil ) {
+			return nil, errors.New( "invalid argument" )
+		}
+
+		// Replace the rule if it exists
+		index := rules.find( rule[ "id" ] )
+		if ( index < 0 ) {
+			return nil, errors.New( "entry not exist" )
+		}
+		rules.Rules[ index ] = rule
+		rules.touch( rule[ "id" ] )
+
+		return nil, nil
+	} )
+
+	// Removes the rule with the given identifier
+	plug.Handle( moduleName, "delete_rule", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+		rules := ruleSet( state )
+
+		// Parse the arguments
+		var parameters struct {
+			Identifier string `json:"id"`
+		}
+		parseError := parseArguments( arguments, &parameters )
+		if ( parseError != nil ) {
+			return nil, errors.New( "invalid argument" )
+		}
+
+		// Remove the rule if it exists
+		index := rules.find( parameters.Identifier )
+		if ( index < 0 ) {
+			return nil, errors.New( "entry not exist" )
+		}
+		rules.Rules = append( rules.Rules[ : index ], rules.Rules[ index + 1 : ]... )
+
+		return nil, nil
+	} )
+
+	// Removes every rule
+	plug.Handle( moduleName, "delete_all_rules", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+		ruleSet( state ).Rules = nil
+
+		return nil, nil
+	} )
+
+	// Enables or disables the rules overall
+	plug.Handle( moduleName, "set_overall_enable", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+
+		// Parse the arguments
+		var parameters struct {
+			Enable *int `json:"enable"`
+		}
+		parseError := parseArguments( arguments, &parameters )
+		if ( parseError != nil || parameters.Enable == nil ) {
+			return nil, errors.New( "invalid argument" )
+		}
+
+		ruleSet( state ).Enabled = ( *parameters.Enable != 0 )
+
+		return nil, nil
+
+	} )
+
+}
+
+// Returns a copy of the rules that shares nothing with the original, so it can be read while the smart plug changes them
+func ( rules RuleSet ) clone() ( RuleSet ) {
+	if ( rules.Rules != nil ) {
+		clonedRules := make( []map[string]any, len( rules.Rules ) )
+		for index, rule := range rules.Rules {
+			clonedRules[ index ] = cloneJSON( rule ).( map[string]any )
+		}
+		rules.Rules = clonedRules
+	}
+	rules.updatedAt = maps.Clone( rules.updatedAt )
+
+	return rules
+}
+
+// Returns a copy of a value parsed from JSON, copying any nested objects & arrays
+func cloneJSON( value any ) ( any ) {
+	switch typedValue := value.( type ) {
+		case map[string]any:
+			if ( typedValue == nil ) {
+				return typedValue
+			}
+			clonedObject := make( map[string]any, len( typedValue ) )
+			for key, nestedValue := range typedValue {
+				clonedObject[ key ] = cloneJSON( nestedValue )
+			}
+			return clonedObject
+		case []any:
+			if ( typedValue == nil ) {
+				return typedValue
+			}
+			clonedArray := make( []any, len( typedValue ) )
+			for index, nestedValue := range typedValue {
+				clonedArray[ index ] = cloneJSON( nestedValue )
+			}
+			return clonedArray
+		default:
+			return value
+	}
+}
+
+// Returns the index of the rule with the given identifier, or -1 if there is none
+func ( rules *RuleSet ) find( identifier any ) ( int ) {
+	for index, rule := range rules.Rules {
+		if ( rule[ "id" ] == identifier ) {
+			return index
+		}
+	}
+
+	return -1
+}
+
+// Remembers that a rule was added or edited just now
+func ( rules *RuleSet ) touch( identifier any ) {
+	if ( rules.updatedAt == nil ) {
+		rules.updatedAt = map[any]time.Time{}
+	}
+
+	rules.updatedAt[ identifier ] = time.Now()
+}
+
+// Checks if the rules are enabled overall & at least one rule is enabled
+func ( rules *RuleSet ) isActive() ( bool ) {
+	if ( !rules.Enabled ) {
+		return false
+	}
+
+	for _, rule := range rules.Rules {
+		enable, _ := rule[ "enable" ].( float64 )
+		if ( enable != 0 ) {
+			return true
+		}
+	}
+
+	return false
+}
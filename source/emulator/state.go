@@ -0,0 +1,227 @@
+package emulator
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/viral32111/kasa-smart-plug/source/kasa"
+)
+
+// Structure for holding the mutable state of a fake smart plug
+type State struct {
+
+	// Device information
+	Alias string
+	DeviceName string
+	Model string
+	DeviceIdentifier string
+	HardwareIdentifier string
+	OEMIdentifier string
+	HardwareVersion string
+	SoftwareVersion string
+	Features string
+	Type string
+	IconHash string
+
+	// Network, & the wireless networks in range
+	MACAddress string
+	SignalStrength int
+	StationSSID string
+	AccessPoints []kasa.AccessPoint
+
+	// Position
+	Latitude float64
+	Longitude float64
+
+	// Runtime & state, the active mode is only used when no rules are enabled
+	RelayState bool
+	LEDOff bool
+	ActiveMode string
+	PoweredOnAt time.Time
+
+	// Time, relative to the host clock
+	ClockOffset time.Duration
+	TimezoneIndex int
+
+	// Real-time energy usage
+	Current float64 // amps
+	Voltage float64 // volts
+	Power float64 // watts
+	TotalEnergy float64 // kilowatt-hours
+
+	// Whether the energy meter responds with the field names & base units of older firmware, such as the HS110 v1
+	LegacyEnergyMeter bool
+
+	// Calibration of the energy meter, the readings are accurate at the default gains & scale linearly with them
+	VoltageGain int
+	CurrentGain int
+
+	// Rules for switching the power relay at times of day
+	Schedule RuleSet
+
+	// Rules for switching the power relay once a delay has passed
+	Countdown RuleSet
+
+	// Rules for randomly switching the power relay within a window, to look like someone is home
+	AntiTheft RuleSet
+
+	// Historical energy usage, keyed by the date in the smart plug's timezone as YYYY-MM-DD
+	DailyEnergy map[string]int // watthours
+
+	// The outlets, only on power strips
+	Children []ChildState
+
+	// The light & its saved states, only on smart bulbs
+	Light *kasa.LightState
+	LightPresets []kasa.LightPreset
+
+	// The brightness & how it fades, only on dimmer switches
+	Dimmer *DimmerState
+
+}
+
+// Structure for holding the mutable state of an outlet on a fake power strip
+type ChildState struct {
+
+	// The full identifier, which is the device identifier followed by the position of the outlet
+	Identifier string
+	Alias string
+
+	// Runtime & state
+	RelayState bool
+	PoweredOnAt time.Time
+
+	// Real-time energy usage
+	Current float64 // amps
+	Voltage float64 // volts
+	Power float64 // watts
+	TotalEnergy float64 // kilowatt-hours
+
+}
+
+// The energy meter gains that give accurate readings
+const (
+	DefaultVoltageGain = 13462
+	DefaultCurrentGain = 16835
+)
+
+// Returns the default state, resembling a KP115 that is switched on
+func DefaultState() ( State ) {
+	return State{
+		Alias: "Emulated Smart Plug",
+		DeviceName: "Smart Wi-Fi Plug Mini",
+		Model: "KP115(UK)",
+		DeviceIdentifier: "80060000000000000000000000000000000000EE",
+		HardwareIdentifier: "00000000000000000000000000000000",
+		OEMIdentifier: "00000000000000000000000000000000",
+		HardwareVersion: "1.0",
+		SoftwareVersion: "1.0.18 Build 210910 Rel.141202",
+		Features: "TIM:ENE",
+		Type: "IOT.SMARTPLUGSWITCH",
+		MACAddress: "00:00:5E:00:53:01",
+		SignalStrength: -50,
+		StationSSID: "Home Network",
+		AccessPoints: []kasa.AccessPoint{
+			{ SSID: "Home Network", KeyType: kasa.KeyTypeWPA2 },
+			{ SSID: "Guest Network", KeyType: kasa.KeyTypeNone },
+		},
+		RelayState: true,
+		LEDOff: false,
+		ActiveMode: "none",
+		PoweredOnAt: time.Now(),
+		TimezoneIndex: 38,
+		Current: 0.25,
+		Voltage: 240.0,
+		Power: 60.0,
+		TotalEnergy: 1.5,
+		VoltageGain: DefaultVoltageGain,
+		CurrentGain: DefaultCurrentGain,
+		DailyEnergy: defaultDailyEnergy( time.Now(), 60 ),
+		Schedule: RuleSet{ Enabled: true },
+		Countdown: RuleSet{ Enabled: true, Limit: 1 },
+		AntiTheft: RuleSet{ Enabled: true },
+	}
+}
+
+// Returns the default state with the given number of outlets, resembling a HS300 with every other outlet switched on
+func DefaultStripState( outlets int ) ( State ) {
+	state := DefaultState()
+	state.Alias = "Emulated Power Strip"
+	state.DeviceName = "Smart Wi-Fi Power Strip"
+	state.Model = "HS300(UK)"
+
+	for position := 1; position <= outlets; position++ {
+		state.Children = append( state.Children, ChildState{
+			Identifier: fmt.Sprintf( "%s%02d", state.DeviceIdentifier, position - 1 ),
+			Alias: fmt.Sprintf( "Outlet %d", position ),
+			RelayState: ( position % 2 == 1 ),
+			PoweredOnAt: time.Now(),
+			Current: 0.1 * float64( position ),
+			Voltage: 240.0,
+			Power: 24.0 * float64( position ),
+			TotalEnergy: 0.5 * float64( position ),
+		} )
+	}
+
+	return state
+}
+
+// Returns a copy of the state that shares nothing with the original, so it can be read while the smart plug changes it
+func ( state State ) clone() ( State ) {
+	state.AccessPoints = slices.Clone( state.AccessPoints )
+	state.Schedule = state.Schedule.clone()
+	state.Countdown = state.Countdown.clone()
+	state.AntiTheft = state.AntiTheft.clone()
+	state.DailyEnergy = maps.Clone( state.DailyEnergy )
+	state.Children = slices.Clone( state.Children )
+	state.LightPresets = slices.Clone( state.LightPresets )
+
+	if ( state.Light != nil ) {
+		light := *state.Light
+		state.Light = &light
+	}
+	if ( state.Dimmer != nil ) {
+		dimmer := *state.Dimmer
+		state.Dimmer = &dimmer
+	}
+
+	return state
+}
+
+// Returns the outlet with the given identifier, which may be only its last two digits
+func ( state *State ) child( identifier string ) ( *ChildState ) {
+	for index := range state.Children {
+		if ( state.Children[ index ].Identifier == identifier || state.Children[ index ].Identifier == state.DeviceIdentifier + identifier ) {
+			return &state.Children[ index ]
+		}
+	}
+
+	return nil
+}
+
+// Returns a repeating weekly pattern of energy usage for the given number of days up to & including today
+func defaultDailyEnergy( today time.Time, days int ) ( map[string]int ) {
+	dailyEnergy := make( map[string]int, days )
+
+	for offset := 0; offset < days; offset++ {
+		date := today.AddDate( 0, 0, -offset )
+		dailyEnergy[ date.Format( time.DateOnly ) ] = 1000 + int( date.Weekday() ) * 100
+	}
+
+	return dailyEnergy
+}
+
+// Returns the mode reported in the system information, from the rules that are enabled
+func ( state *State ) activeMode() ( string ) {
+	if ( state.Countdown.isActive() ) {
+		return "count_down"
+	} else if ( state.AntiTheft.isActive() ) {
+		return "anti_theft"
+	} else if ( state.Schedule.isActive() ) {
+		return "schedule"
+	}
+
+	return state.ActiveMode
+}
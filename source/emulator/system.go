@@ -0,0 +1,255 @@
+package emulator
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"time"
+)
+
+// Registers the handlers for the system module
+func ( plug *Plug ) registerSystemHandlers() {
+
+	// Returns the system information
+	plug.Handle( "system", "get_sysinfo", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+
+		// The uptime is only counted while the relay is on
+		onTime := 0
+		if ( state.RelayState ) {
+			onTime = int( time.Since( state.PoweredOnAt ).Seconds() )
+		}
+
+		info := map[string]any{
+			"sw_ver": state.SoftwareVersion,
+			"hw_ver": state.HardwareVersion,
+			"model": state.Model,
+			"deviceId": state.DeviceIdentifier,
+			"oemId": state.OEMIdentifier,
+			"hwId": state.HardwareIdentifier,
+			"rssi": state.SignalStrength,
+			"latitude_i": int( math.Round( state.Latitude * 10000.0 ) ),
+			"longitude_i": int( math.Round( state.Longitude * 10000.0 ) ),
+			"alias": state.Alias,
+			"status": "new",
+			"obd_src": "tplink",
+			"mic_type": state.Type,
+			"feature": state.Features,
+			"mac": state.MACAddress,
+			"updating": 0,
+			"led_off": boolToInt( state.LEDOff ),
+			"relay_state": boolToInt( state.RelayState ),
+			"on_time": onTime,
+			"icon_hash": state.IconHash,
+			"dev_name": state.DeviceName,
+			"active_mode": state.activeMode(),
+			"next_action": map[string]any{ "type": -1 },
+			"ntc_state": 0,
+		}
+
+		// Include the brightness, on dimmer switches
+		if ( state.Dimmer != nil ) {
+			info[ "brightness" ] = state.Dimmer.Brightness
+		}
+
+		// Include the outlets, on power strips
+		if ( len( state.Children ) > 0 ) {
+			children := []map[string]any{}
+			for _, child := range state.Children {
+				childOnTime := 0
+				if ( child.RelayState ) {
+					childOnTime = int( time.Since( child.PoweredOnAt ).Seconds() )
+				}
+
+				children = append( children, map[string]any{
+					"id": child.Identifier,
+					"state": boolToInt( child.RelayState ),
+					"alias": child.Alias,
+					"on_time": childOnTime,
+					"next_action": map[string]any{ "type": -1 },
+				} )
+			}
+
+			info[ "children" ] = children
+			info[ "child_num" ] = len( children )
+		}
+
+		return info, nil
+
+	} )
+
+	// Switches the relay on or off
+	plug.Handle( "system", "set_relay_state", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+
+		// Parse the arguments
+		var parameters struct {
+			State *int `json:"state"`
+		}
+		parseError := parseArguments( arguments, &parameters )
+		if ( parseError != nil || parameters.State == nil ) {
+			return nil, errors.New( "invalid argument" )
+		}
+
+		// Restart the uptime if the relay is being switched on
+		relayState := ( *parameters.State != 0 )
+		if ( relayState && !state.RelayState ) {
+			state.PoweredOnAt = time.Now()
+		}
+		state.RelayState = relayState
+
+		return nil, nil
+
+	} )
+
+	// Switches the relay of an outlet on or off
+	plug.HandleChild( "system", "set_relay_state", func( state *State, child *ChildState, arguments json.RawMessage ) ( map[string]any, error ) {
+
+		// Parse the arguments
+		var parameters struct {
+			State *int `json:"state"`
+		}
+		parseError := parseArguments( arguments, &parameters )
+		if ( parseError != nil || parameters.State == nil ) {
+			return nil, errors.New( "invalid argument" )
+		}
+
+		// Restart the uptime if the relay is being switched on
+		relayState := ( *parameters.State != 0 )
+		if ( relayState && !child.RelayState ) {
+			child.PoweredOnAt = time.Now()
+		}
+		child.RelayState = relayState
+
+		return nil, nil
+
+	} )
+
+	// Switches the indicator light on or off
+	plug.Handle( "system", "set_led_off", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+
+		// Parse the arguments
+		var parameters struct {
+			Off *int `json:"off"`
+		}
+		parseError := parseArguments( arguments, &parameters )
+		if ( parseError != nil || parameters.Off == nil ) {
+			return nil, errors.New( "invalid argument" )
+		}
+
+		state.LEDOff = ( *parameters.Off != 0 )
+
+		return nil, nil
+
+	} )
+
+	// Renames the smart plug
+	plug.Handle( "system", "set_dev_alias", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+
+		// Parse the arguments
+		var parameters struct {
+			Alias *string `json:"alias"`
+		}
+		parseError := parseArguments( arguments, &parameters )
+		if ( parseError != nil || parameters.Alias == nil ) {
+			return nil, errors.New( "invalid argument" )
+		}
+
+		state.Alias = *parameters.Alias
+
+		return nil, nil
+
+	} )
+
+	// Renames an outlet
+	plug.HandleChild( "system", "set_dev_alias", func( state *State, child *ChildState, arguments json.RawMessage ) ( map[string]any, error ) {
+
+		// Parse the arguments
+		var parameters struct {
+			Alias *string `json:"alias"`
+		}
+		parseError := parseArguments( arguments, &parameters )
+		if ( parseError != nil || parameters.Alias == nil ) {
+			return nil, errors.New( "invalid argument" )
+		}
+
+		child.Alias = *parameters.Alias
+
+		return nil, nil
+
+	} )
+
+	// Sets the position
+	plug.Handle( "system", "set_dev_location", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+
+		// Parse the arguments
+		var parameters struct {
+			Latitude *float64 `json:"latitude"`
+			Longitude *float64 `json:"longitude"`
+		}
+		parseError := parseArguments( arguments, &parameters )
+		if ( parseError != nil || parameters.Latitude == nil || parameters.Longitude == nil ) {
+			return nil, errors.New( "invalid argument" )
+		}
+
+		state.Latitude = *parameters.Latitude
+		state.Longitude = *parameters.Longitude
+
+		return nil, nil
+
+	} )
+
+	// Sets the icon, ignoring the image data
+	plug.Handle( "system", "set_dev_icon", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+
+		// Parse the arguments
+		var parameters struct {
+			Hash *string `json:"hash"`
+		}
+		parseError := parseArguments( arguments, &parameters )
+		if ( parseError != nil || parameters.Hash == nil ) {
+			return nil, errors.New( "invalid argument" )
+		}
+
+		state.IconHash = *parameters.Hash
+
+		return nil, nil
+
+	} )
+
+	// Pretends to restart, which only resets the uptime
+	plug.Handle( "system", "reboot", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+		state.PoweredOnAt = time.Now()
+
+		return nil, nil
+	} )
+
+	// Erases all settings, keeping only what is fixed in the hardware & firmware
+	plug.Handle( "system", "reset", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+		resetState := DefaultState()
+		if ( state.Light != nil ) {
+			resetState = DefaultBulbState()
+		} else if ( state.Dimmer != nil ) {
+			resetState = DefaultDimmerState()
+		}
+		resetState.MACAddress = state.MACAddress
+		resetState.DeviceIdentifier = state.DeviceIdentifier
+		resetState.Model = state.Model
+		resetState.LegacyEnergyMeter = state.LegacyEnergyMeter
+		resetState.Children = state.Children
+		resetState.StationSSID = ""
+		resetState.DailyEnergy = map[string]int{}
+		resetState.TotalEnergy = 0
+		*state = resetState
+
+		return nil, nil
+	} )
+
+}
+
+// Converts a boolean to the integer used by the smart plug
+func boolToInt( value bool ) ( int ) {
+	if ( value ) {
+		return 1
+	}
+
+	return 0
+}
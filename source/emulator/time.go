@@ -0,0 +1,117 @@
+package emulator
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/viral32111/kasa-smart-plug/source/kasa"
+)
+
+// Registers the handlers for the time module
+func ( plug *Plug ) registerTimeHandlers() {
+
+	// Returns the current date & time of the smart plug's clock
+	plug.Handle( "time", "get_time", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+		now := state.Now()
+
+		return map[string]any{
+			"year": now.Year(),
+			"month": int( now.Month() ),
+			"mday": now.Day(),
+			"hour": now.Hour(),
+			"min": now.Minute(),
+			"sec": now.Second(),
+		}, nil
+	} )
+
+	// Returns the index of the smart plug's timezone
+	plug.Handle( "time", "get_timezone", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+		return map[string]any{
+			"index": state.TimezoneIndex,
+		}, nil
+	} )
+
+	// Sets the smart plug's clock, in its timezone
+	plug.Handle( "time", "set_time", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+
+		// Parse the arguments
+		var parameters clockArguments
+		parseError := parseArguments( arguments, &parameters )
+		if ( parseError != nil || !parameters.isValid() ) {
+			return nil, errors.New( "invalid argument" )
+		}
+
+		// Store the clock as an offset from the host clock
+		state.ClockOffset = parameters.offsetFrom( state.Now().Location() )
+
+		return nil, nil
+
+	} )
+
+	// Sets the smart plug's timezone & clock together
+	plug.Handle( "time", "set_timezone", func( state *State, arguments json.RawMessage ) ( map[string]any, error ) {
+
+		// Parse the arguments
+		var parameters struct {
+			clockArguments
+			Index *int `json:"index"`
+		}
+		parseError := parseArguments( arguments, &parameters )
+		if ( parseError != nil || parameters.Index == nil || !parameters.isValid() ) {
+			return nil, errors.New( "invalid argument" )
+		}
+
+		// Fail if the timezone index is not known
+		location, locationError := kasa.TimezoneLocation( *parameters.Index )
+		if ( locationError != nil ) {
+			return nil, errors.New( "invalid argument" )
+		}
+
+		// Store the timezone & the clock as an offset from the host clock
+		state.TimezoneIndex = *parameters.Index
+		state.ClockOffset = parameters.offsetFrom( location )
+
+		return nil, nil
+
+	} )
+
+}
+
+// Returns the current time of the smart plug's clock, in its timezone
+func ( state *State ) Now() ( time.Time ) {
+
+	// Fall back to UTC if the timezone index is not known
+	location, locationError := kasa.TimezoneLocation( state.TimezoneIndex )
+	if ( locationError != nil ) {
+		location = time.UTC
+	}
+
+	return time.Now().Add( state.ClockOffset ).In( location )
+
+}
+
+// Structure for the date & time arguments of the set_time & set_timezone methods
+type clockArguments struct {
+	Year *int `json:"year"`
+	Month *int `json:"month"`
+	Day *int `json:"mday"`
+	Hour *int `json:"hour"`
+	Minute *int `json:"min"`
+	Second *int `json:"sec"`
+}
+
+// Checks that every field is present & within range
+func ( arguments clockArguments ) isValid() ( bool ) {
+	if ( arguments.Year == nil || arguments.Month == nil || arguments.Day == nil || arguments.Hour == nil || arguments.Minute == nil || arguments.Second == nil ) {
+		return false
+	}
+
+	return *arguments.Year >= 2000 && *arguments.Month >= 1 && *arguments.Month <= 12 && *arguments.Day >= 1 && *arguments.Day <= 31 && *arguments.Hour >= 0 && *arguments.Hour <= 23 && *arguments.Minute >= 0 && *arguments.Minute <= 59 && *arguments.Second >= 0 && *arguments.Second <= 59
+}
+
+// Returns the offset of the wall clock in the given timezone from the host clock
+func ( arguments clockArguments ) offsetFrom( location *time.Location ) ( time.Duration ) {
+	clock := time.Date( *arguments.Year, time.Month( *arguments.Month ), *arguments.Day, *arguments.Hour, *arguments.Minute, *arguments.Second, 0, location )
+	return time.Until( clock ).Round( time.Second )
+}
@@ -0,0 +1,196 @@
+package kasa
+
+import (
+	"context"
+	"errors"
+)
+
+// The modes the smart plug reports as active in its system information
+const (
+	ActiveModeNone = "none"
+	ActiveModeSchedule = "schedule"
+	ActiveModeCountdown = "count_down"
+	ActiveModeAway = "anti_theft"
+)
+
+// Structure for holding a rule that randomly switches the power relay on & off within a window, to make it look like someone is home
+type AwayRule struct {
+	Identifier string // Assigned by the smart plug
+	Name string
+	Enabled bool
+
+	// The days of the week to repeat on, starting from Sunday
+	Weekdays [ 7 ]bool
+
+	// When the window starts, either minutes after midnight, or minutes before (negative) or after sunrise or sunset
+	StartReference TimeReference
+	StartMinutes int
+	StartOffset int
+
+	// When the window ends, in the same way as the start
+	EndReference TimeReference
+	EndMinutes int
+	EndOffset int
+
+	// How often to switch within the window
+	Frequency int
+}
+
+// Structure for an away rule as sent & received by the smart plug
+type awayRuleJSON struct {
+	Identifier string `json:"id,omitempty"`
+	Name string `json:"name"`
+	Enable int `json:"enable"`
+	Weekdays [ 7 ]int `json:"wday"`
+	Repeat int `json:"repeat"`
+	StartReference int `json:"stime_opt"`
+	StartMinutes int `json:"smin"`
+	StartOffset int `json:"soffset"`
+	EndReference int `json:"etime_opt"`
+	EndMinutes int `json:"emin"`
+	EndOffset int `json:"eoffset"`
+	Frequency int `json:"frequency"`
+	Year int `json:"year"`
+	Month int `json:"month"`
+	Day int `json:"day"`
+}
+
+// Checks the rule can be sent to the smart plug
+func ( rule AwayRule ) validate() ( error ) {
+
+	// Require at least one day, as one-off rules need a date that only the smart plug knows
+	weekdaysError := validateWeekdays( rule.Weekdays )
+	if ( weekdaysError != nil ) {
+		return weekdaysError
+	}
+
+	// Require valid start & end times
+	startError := validateRuleTime( rule.StartReference, rule.StartMinutes, rule.StartOffset )
+	if ( startError != nil ) {
+		return startError
+	}
+	endError := validateRuleTime( rule.EndReference, rule.EndMinutes, rule.EndOffset )
+	if ( endError != nil ) {
+		return endError
+	}
+
+	// Require switching at least once
+	if ( rule.Frequency <= 0 ) {
+		return errors.New( "away rule frequency must be greater than 0" )
+	}
+
+	// Return no error
+	return nil
+
+}
+
+// Converts the rule to how the smart plug expects it
+func ( rule AwayRule ) toJSON() ( awayRuleJSON ) {
+	ruleJSON := awayRuleJSON{
+		Identifier: rule.Identifier,
+		Name: rule.Name,
+		Enable: boolToInt( rule.Enabled ),
+		Weekdays: weekdaysToJSON( rule.Weekdays ),
+		Repeat: 1,
+		StartReference: int( rule.StartReference ),
+		StartMinutes: rule.StartMinutes,
+		EndReference: int( rule.EndReference ),
+		EndMinutes: rule.EndMinutes,
+		Frequency: rule.Frequency,
+	}
+
+	// The offsets only apply to sunrise & sunset
+	if ( rule.StartReference != TimeOfDay ) {
+		ruleJSON.StartOffset = rule.StartOffset
+	}
+	if ( rule.EndReference != TimeOfDay ) {
+		ruleJSON.EndOffset = rule.EndOffset
+	}
+
+	return ruleJSON
+}
+
+// Converts the rule from how the smart plug sent it
+func ( ruleJSON awayRuleJSON ) toRule() ( AwayRule ) {
+	return AwayRule{
+		Identifier: ruleJSON.Identifier,
+		Name: ruleJSON.Name,
+		Enabled: ( ruleJSON.Enable != 0 ),
+		Weekdays: weekdaysFromJSON( ruleJSON.Weekdays ),
+		StartReference: TimeReference( ruleJSON.StartReference ),
+		StartMinutes: ruleJSON.StartMinutes,
+		StartOffset: ruleJSON.StartOffset,
+		EndReference: TimeReference( ruleJSON.EndReference ),
+		EndMinutes: ruleJSON.EndMinutes,
+		EndOffset: ruleJSON.EndOffset,
+		Frequency: ruleJSON.Frequency,
+	}
+}
+
+// Get the away rules, & whether away mode is enabled overall
+func ( client *Client ) GetAwayRules( ctx context.Context ) ( []AwayRule, bool, error ) {
+
+	// Fetch the rules
+	var rulesJSON []awayRuleJSON
+	enabled, rulesError := client.getRules( ctx, "anti_theft", &rulesJSON )
+	if ( rulesError != nil ) {
+		return nil, false, rulesError
+	}
+
+	// Convert each rule
+	rules := make( []AwayRule, len( rulesJSON ) )
+	for index, ruleJSON := range rulesJSON {
+		rules[ index ] = ruleJSON.toRule()
+	}
+
+	// Return the rules
+	return rules, enabled, nil
+
+}
+
+// Adds an away rule & returns the identifier that the smart plug assigned to it
+func ( client *Client ) AddAwayRule( ctx context.Context, rule AwayRule ) ( string, error ) {
+
+	// Fail if the rule is invalid
+	validateError := rule.validate()
+	if ( validateError != nil ) {
+		return "", validateError
+	}
+
+	// Add the rule, without an identifier
+	rule.Identifier = ""
+	return client.addRule( ctx, "anti_theft", rule.toJSON() )
+
+}
+
+// Replaces an existing away rule with the same identifier
+func ( client *Client ) EditAwayRule( ctx context.Context, rule AwayRule ) ( error ) {
+
+	// Fail if the rule is invalid or does not say which rule to replace
+	if ( rule.Identifier == "" ) {
+		return errors.New( "away rule has no identifier" )
+	}
+	validateError := rule.validate()
+	if ( validateError != nil ) {
+		return validateError
+	}
+
+	// Replace the rule
+	return client.editRule( ctx, "anti_theft", rule.toJSON() )
+
+}
+
+// Removes an away rule
+func ( client *Client ) DeleteAwayRule( ctx context.Context, identifier string ) ( error ) {
+	return client.deleteRule( ctx, "anti_theft", identifier )
+}
+
+// Removes every away rule
+func ( client *Client ) DeleteAllAwayRules( ctx context.Context ) ( error ) {
+	return client.deleteAllRules( ctx, "anti_theft" )
+}
+
+// Enables or disables away mode overall, without changing whether each rule is enabled
+func ( client *Client ) SetAwayEnabled( ctx context.Context, enabled bool ) ( error ) {
+	return client.setRulesEnabled( ctx, "anti_theft", enabled )
+}
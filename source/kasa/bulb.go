@@ -0,0 +1,302 @@
+package kasa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// The module that smart bulbs, such as the KL130, use for the light
+const lightingServiceModule = "smartlife.iot.smartbulb.lightingservice"
+
+// The range of colour temperatures most smart bulbs accept, in kelvin
+const (
+	MinColourTemperature = 2500
+	MaxColourTemperature = 9000
+)
+
+// Structure for holding a snapshot of the data about a smart bulb
+type SmartBulb struct {
+
+	// Runtime & state
+	Alias string
+	Light LightState
+
+	// Device information
+	Description string
+	DeviceModel string
+	DeviceIdentifier string
+	HardwareVersion string
+	HardwareIdentifier string
+	OEMIdentifier string
+	FirmwareVersion string
+	Type string
+
+	// Network
+	SignalStrength int
+	MACAddress string
+
+	// What the light can do
+	IsDimmable bool
+	IsColour bool
+	IsVariableColourTemperature bool
+
+	// The saved light states, applied with ApplyLightPreset
+	Presets []LightPreset
+
+}
+
+// Structure for holding the state of the light on a smart bulb.
+// The colour temperature is zero when the hue & saturation are in use, otherwise they are ignored.
+type LightState struct {
+	PowerState bool
+	Mode string
+	Hue int // degrees, 0 to 360
+	Saturation int // percent
+	ColourTemperature int // kelvin
+	Brightness int // percent
+}
+
+// Structure for holding a saved light state, by its position on the smart bulb
+type LightPreset struct {
+	Index int
+	Hue int // degrees, 0 to 360
+	Saturation int // percent
+	ColourTemperature int // kelvin
+	Brightness int // percent
+}
+
+// Structure for a change to the light state, leaving anything nil as it is
+type LightStateChange struct {
+	PowerState *bool
+	Hue *int
+	Saturation *int
+	ColourTemperature *int
+	Brightness *int
+
+	// How long the smart bulb takes to fade to the new state, in whole milliseconds
+	Transition time.Duration
+}
+
+// Structure for the light state as sent by the smart bulb, which holds the state it turns on with while it is off
+type lightStateJSON struct {
+	PowerState int `json:"on_off"`
+	Mode string `json:"mode"`
+	Hue int `json:"hue"`
+	Saturation int `json:"saturation"`
+	ColourTemperature int `json:"color_temp"`
+	Brightness int `json:"brightness"`
+	DefaultOnState *struct {
+		Mode string `json:"mode"`
+		Hue int `json:"hue"`
+		Saturation int `json:"saturation"`
+		ColourTemperature int `json:"color_temp"`
+		Brightness int `json:"brightness"`
+	} `json:"dft_on_state"`
+}
+
+// Structure for a saved light state as sent & received by the smart bulb
+type lightPresetJSON struct {
+	Index int `json:"index"`
+	Hue int `json:"hue"`
+	Saturation int `json:"saturation"`
+	ColourTemperature int `json:"color_temp"`
+	Brightness int `json:"brightness"`
+}
+
+// Converts the light state from how the smart bulb sent it, using the state it turns on with while it is off
+func ( stateJSON lightStateJSON ) toLightState() ( LightState ) {
+	if ( stateJSON.DefaultOnState != nil ) {
+		return LightState{
+			PowerState: ( stateJSON.PowerState != 0 ),
+			Mode: stateJSON.DefaultOnState.Mode,
+			Hue: stateJSON.DefaultOnState.Hue,
+			Saturation: stateJSON.DefaultOnState.Saturation,
+			ColourTemperature: stateJSON.DefaultOnState.ColourTemperature,
+			Brightness: stateJSON.DefaultOnState.Brightness,
+		}
+	}
+
+	return LightState{
+		PowerState: ( stateJSON.PowerState != 0 ),
+		Mode: stateJSON.Mode,
+		Hue: stateJSON.Hue,
+		Saturation: stateJSON.Saturation,
+		ColourTemperature: stateJSON.ColourTemperature,
+		Brightness: stateJSON.Brightness,
+	}
+}
+
+// Checks the change can be sent to the smart bulb
+func ( change LightStateChange ) validate() ( error ) {
+	if ( change.Hue != nil && ( *change.Hue < 0 || *change.Hue > 360 ) ) {
+		return fmt.Errorf( "invalid hue %d, must be between 0 and 360", *change.Hue )
+	}
+	if ( change.Saturation != nil && ( *change.Saturation < 0 || *change.Saturation > 100 ) ) {
+		return fmt.Errorf( "invalid saturation %d, must be between 0 and 100", *change.Saturation )
+	}
+	if ( change.ColourTemperature != nil && *change.ColourTemperature != 0 && ( *change.ColourTemperature < MinColourTemperature || *change.ColourTemperature > MaxColourTemperature ) ) {
+		return fmt.Errorf( "invalid colour temperature %d, must be between %d and %d", *change.ColourTemperature, MinColourTemperature, MaxColourTemperature )
+	}
+	if ( change.Brightness != nil && ( *change.Brightness < 0 || *change.Brightness > 100 ) ) {
+		return fmt.Errorf( "invalid brightness %d, must be between 0 and 100", *change.Brightness )
+	}
+	if ( change.Transition < 0 ) {
+		return errors.New( "transition cannot be negative" )
+	}
+
+	return nil
+}
+
+// Fetches the system information of a smart bulb
+func ( client *Client ) GetBulbInformation( ctx context.Context ) ( SmartBulb, error ) {
+
+	// Fetch the system information, which is shaped differently to that of a smart plug
+	var info struct {
+		SoftwareVersion string `json:"sw_ver"`
+		HardwareVersion string `json:"hw_ver"`
+		Model string `json:"model"`
+		Description string `json:"description"`
+		Alias string `json:"alias"`
+		Type string `json:"mic_type"`
+		MACAddress string `json:"mic_mac"`
+		DeviceIdentifier string `json:"deviceId"`
+		OEMIdentifier string `json:"oemId"`
+		HardwareIdentifier string `json:"hwId"`
+		SignalStrength int `json:"rssi"`
+		IsDimmable int `json:"is_dimmable"`
+		IsColour int `json:"is_color"`
+		IsVariableColourTemperature int `json:"is_variable_color_temp"`
+		LightState lightStateJSON `json:"light_state"`
+		Presets []lightPresetJSON `json:"preferred_state"`
+	}
+	infoError := client.call( ctx, "system", "get_sysinfo", nil, &info )
+	if ( infoError != nil ) {
+		return SmartBulb{}, infoError
+	}
+
+	// Convert each preset
+	presets := make( []LightPreset, len( info.Presets ) )
+	for index, presetJSON := range info.Presets {
+		presets[ index ] = LightPreset( presetJSON )
+	}
+
+	// Populate the snapshot from the response
+	return SmartBulb{
+		Alias: info.Alias,
+		Light: info.LightState.toLightState(),
+		Description: info.Description,
+		DeviceModel: info.Model,
+		DeviceIdentifier: info.DeviceIdentifier,
+		HardwareVersion: info.HardwareVersion,
+		HardwareIdentifier: info.HardwareIdentifier,
+		OEMIdentifier: info.OEMIdentifier,
+		FirmwareVersion: info.SoftwareVersion,
+		Type: info.Type,
+		SignalStrength: info.SignalStrength,
+		MACAddress: info.MACAddress,
+		IsDimmable: ( info.IsDimmable != 0 ),
+		IsColour: ( info.IsColour != 0 ),
+		IsVariableColourTemperature: ( info.IsVariableColourTemperature != 0 ),
+		Presets: presets,
+	}, nil
+
+}
+
+// Get the state of the light on a smart bulb
+func ( client *Client ) GetBulbState( ctx context.Context ) ( LightState, error ) {
+
+	// Fetch the light state
+	var stateJSON lightStateJSON
+	stateError := client.call( ctx, lightingServiceModule, "get_light_state", nil, &stateJSON )
+	if ( stateError != nil ) {
+		return LightState{}, stateError
+	}
+
+	// Return the light state
+	return stateJSON.toLightState(), nil
+
+}
+
+// Changes the state of the light on a smart bulb, fading over the transition, & returns the new state
+func ( client *Client ) SetBulbState( ctx context.Context, change LightStateChange ) ( LightState, error ) {
+
+	// Fail if the change is invalid
+	validateError := change.validate()
+	if ( validateError != nil ) {
+		return LightState{}, validateError
+	}
+
+	// Only include what is changing, ignoring the state the smart bulb would otherwise turn on with
+	arguments := map[string]int {
+		"ignore_default": 1,
+		"transition_period": int( change.Transition / time.Millisecond ),
+	}
+	if ( change.PowerState != nil ) {
+		arguments[ "on_off" ] = boolToInt( *change.PowerState )
+	}
+	if ( change.Hue != nil ) {
+		arguments[ "hue" ] = *change.Hue
+	}
+	if ( change.Saturation != nil ) {
+		arguments[ "saturation" ] = *change.Saturation
+	}
+	if ( change.Brightness != nil ) {
+		arguments[ "brightness" ] = *change.Brightness
+	}
+
+	// Switch to colour by clearing the colour temperature, unless one is given
+	if ( change.ColourTemperature != nil ) {
+		arguments[ "color_temp" ] = *change.ColourTemperature
+	} else if ( change.Hue != nil || change.Saturation != nil ) {
+		arguments[ "color_temp" ] = 0
+	}
+
+	// Send the transition command, which responds with the new state
+	var stateJSON lightStateJSON
+	transitionError := client.call( ctx, lightingServiceModule, "transition_light_state", arguments, &stateJSON )
+	if ( transitionError != nil ) {
+		return LightState{}, transitionError
+	}
+
+	// Return the new state
+	return stateJSON.toLightState(), nil
+
+}
+
+// Switches the light of a smart bulb on or off, fading over the transition
+func ( client *Client ) SetBulbPowerState( ctx context.Context, powerState bool, transition time.Duration ) ( error ) {
+	_, setError := client.SetBulbState( ctx, LightStateChange{ PowerState: &powerState, Transition: transition } )
+	return setError
+}
+
+// Switches the light of a smart bulb on to a saved light state, fading over the transition
+func ( client *Client ) ApplyLightPreset( ctx context.Context, preset LightPreset, transition time.Duration ) ( LightState, error ) {
+	powerState := true
+	return client.SetBulbState( ctx, LightStateChange{
+		PowerState: &powerState,
+		Hue: &preset.Hue,
+		Saturation: &preset.Saturation,
+		ColourTemperature: &preset.ColourTemperature,
+		Brightness: &preset.Brightness,
+		Transition: transition,
+	} )
+}
+
+// Saves a light state in place of the preset at the same index
+func ( client *Client ) SaveLightPreset( ctx context.Context, preset LightPreset ) ( error ) {
+
+	// Fail if the preset is invalid
+	validateError := LightStateChange{ Hue: &preset.Hue, Saturation: &preset.Saturation, ColourTemperature: &preset.ColourTemperature, Brightness: &preset.Brightness }.validate()
+	if ( validateError != nil ) {
+		return validateError
+	}
+	if ( preset.Index < 0 ) {
+		return fmt.Errorf( "invalid preset index %d", preset.Index )
+	}
+
+	// Send the preset command
+	return client.call( ctx, lightingServiceModule, "set_preferred_state", lightPresetJSON( preset ), nil )
+
+}
@@ -0,0 +1,92 @@
+package kasa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Structure for holding the calibration of the energy meter, which scales the raw voltage & current readings
+type Calibration struct {
+	VoltageGain int
+	CurrentGain int
+}
+
+// Get the calibration of the energy meter
+func ( client *Client ) GetCalibration( ctx context.Context ) ( Calibration, error ) {
+
+	// Fail if the smart plug has no energy meter
+	requireError := client.require( ctx, CapabilityEnergyMeter )
+	if ( requireError != nil ) {
+		return Calibration{}, requireError
+	}
+
+	// Send the gains command
+	queryResponse, queryError := client.SendQuery( ctx, "emeter", "get_vgain_igain", nil )
+	if ( queryError != nil ) {
+		return Calibration{}, queryError
+	}
+
+	// Return the gains
+	return Calibration{
+		VoltageGain: queryResponse.EnergyMeter.Gains.VoltageGain,
+		CurrentGain: queryResponse.EnergyMeter.Gains.CurrentGain,
+	}, nil
+
+}
+
+// Sets the calibration of the energy meter
+func ( client *Client ) SetCalibration( ctx context.Context, calibration Calibration ) ( error ) {
+
+	// Require positive gains, as zero would stop the energy meter from measuring anything
+	if ( calibration.VoltageGain <= 0 || calibration.CurrentGain <= 0 ) {
+		return fmt.Errorf( "invalid gains %d & %d, must be greater than 0", calibration.VoltageGain, calibration.CurrentGain )
+	}
+
+	// Fail if the smart plug has no energy meter
+	requireError := client.require( ctx, CapabilityEnergyMeter )
+	if ( requireError != nil ) {
+		return requireError
+	}
+
+	// Send the gains command
+	_, queryError := client.SendQuery( ctx, "emeter", "set_vgain_igain", map[string]int {
+		"vgain": calibration.VoltageGain,
+		"igain": calibration.CurrentGain,
+	} )
+	if ( queryError != nil ) {
+		return queryError
+	}
+
+	// Return no error
+	return nil
+
+}
+
+// Returns the calibration that would make the measured energy usage match the reference voltage & current, such as from a multimeter.
+// The readings scale linearly with the gains, so each gain is multiplied by how far off its reading is. A reference of zero keeps that gain.
+func ( calibration Calibration ) Calibrate( measured EnergyUsage, referenceVoltage float64, referenceAmperage float64 ) ( Calibration, error ) {
+	calibrated := calibration
+
+	// Scale the voltage gain
+	if ( referenceVoltage > 0 ) {
+		if ( measured.Voltage <= 0 ) {
+			return Calibration{}, errors.New( "cannot calibrate voltage as none was measured" )
+		}
+
+		calibrated.VoltageGain = int( math.Round( float64( calibration.VoltageGain ) * referenceVoltage / measured.Voltage ) )
+	}
+
+	// Scale the current gain
+	if ( referenceAmperage > 0 ) {
+		if ( measured.Amperage <= 0 ) {
+			return Calibration{}, errors.New( "cannot calibrate current as none was measured, is an appliance switched on?" )
+		}
+
+		calibrated.CurrentGain = int( math.Round( float64( calibration.CurrentGain ) * referenceAmperage / measured.Amperage ) )
+	}
+
+	// Return the new gains
+	return calibrated, nil
+}
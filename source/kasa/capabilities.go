@@ -0,0 +1,158 @@
+package kasa
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Something a device can do, which decides the modules it answers
+type Capability string
+
+const (
+	CapabilityRelay Capability = "power switching" // system relay & indicator light
+	CapabilityClock Capability = "clock" // time module
+	CapabilityTimer Capability = "schedules & timers" // schedule, count_down & anti_theft modules, from the TIM feature
+	CapabilityEnergyMeter Capability = "energy monitoring" // emeter module, from the ENE feature
+	CapabilityOutlets Capability = "outlets" // power strips
+	CapabilityDimmer Capability = "dimming" // smartlife.iot.dimmer module
+	CapabilityLight Capability = "smart bulb lighting" // smartlife.iot.smartbulb.lightingservice module
+)
+
+// The device types reported in the system information
+const (
+	DeviceTypePlug = "IOT.SMARTPLUGSWITCH"
+	DeviceTypeBulb = "IOT.SMARTBULB"
+)
+
+// The start of the models of dimmer switches, which report the same device type as smart plugs
+var dimmerModelPrefixes = []string{ "HS220", "KS220", "KS230", "KP405", "ES20M" }
+
+// The set of things a device can do
+type Capabilities map[Capability]bool
+
+// Works out what a device can do from the feature string (e.g., TIM:ENE), model, device type & number of outlets in its system information
+func DetectCapabilities( features string, model string, deviceType string, childCount int ) ( Capabilities ) {
+	capabilities := Capabilities{}
+
+	// Smart bulbs use their own modules for everything, so only the light is known to work
+	if ( deviceType == DeviceTypeBulb ) {
+		capabilities[ CapabilityLight ] = true
+		return capabilities
+	}
+
+	// Everything else switches power & keeps the time like a smart plug
+	capabilities[ CapabilityRelay ] = true
+	capabilities[ CapabilityClock ] = true
+
+	// Use the feature string for the timer & energy meter
+	for _, feature := range strings.Split( features, ":" ) {
+		if ( feature == "TIM" ) {
+			capabilities[ CapabilityTimer ] = true
+		} else if ( feature == "ENE" ) {
+			capabilities[ CapabilityEnergyMeter ] = true
+		}
+	}
+
+	// Use the outlets for power strips
+	if ( childCount > 0 ) {
+		capabilities[ CapabilityOutlets ] = true
+	}
+
+	// Use the model for dimmer switches
+	for _, prefix := range dimmerModelPrefixes {
+		if ( strings.HasPrefix( model, prefix ) ) {
+			capabilities[ CapabilityDimmer ] = true
+		}
+	}
+
+	return capabilities
+}
+
+// Checks if the device can do something
+func ( capabilities Capabilities ) Has( capability Capability ) ( bool ) {
+	return capabilities[ capability ]
+}
+
+// Lists what the device can do, in alphabetical order
+func ( capabilities Capabilities ) List() ( []Capability ) {
+	list := []Capability{}
+	for capability, isCapable := range capabilities {
+		if ( isCapable ) {
+			list = append( list, capability )
+		}
+	}
+	sort.Slice( list, func( a int, b int ) bool { return list[ a ] < list[ b ] } )
+
+	return list
+}
+
+// Returns an error naming the model if the device cannot do something, which matches ErrModuleNotSupported with errors.Is
+func ( capabilities Capabilities ) Require( capability Capability, model string ) ( error ) {
+	if ( capabilities.Has( capability ) ) {
+		return nil
+	}
+
+	return &UnsupportedError{ Capability: capability, Model: model }
+}
+
+// Remembers what the smart plug can do on the client of the power strip itself, so outlets share it
+func ( client *Client ) rememberCapabilities( capabilities Capabilities, model string ) {
+	if ( client.parent != nil ) {
+		client.parent.rememberCapabilities( capabilities, model )
+		return
+	}
+
+	client.capabilitiesMutex.Lock()
+	defer client.capabilitiesMutex.Unlock()
+
+	client.capabilities = capabilities
+	client.model = model
+}
+
+// Returns an error if the smart plug cannot do something, only fetching the system information if it is not already known
+func ( client *Client ) require( ctx context.Context, capability Capability ) ( error ) {
+
+	// Use what the power strip itself can do, if this is for an outlet
+	if ( client.parent != nil ) {
+		return client.parent.require( ctx, capability )
+	}
+
+	// Use the capabilities if they are already known
+	client.capabilitiesMutex.Lock()
+	capabilities, model := client.capabilities, client.model
+	client.capabilitiesMutex.Unlock()
+	if ( capabilities != nil ) {
+		return capabilities.Require( capability, model )
+	}
+
+	// Otherwise, fetch the system information to find out
+	smartPlug, infoError := client.GetSystemInformation( ctx )
+	if ( infoError != nil ) {
+		return infoError
+	}
+
+	return smartPlug.Capabilities.Require( capability, smartPlug.DeviceModel )
+
+}
+
+// Structure for holding an error for something the model cannot do
+type UnsupportedError struct {
+	Capability Capability
+	Model string
+}
+
+// Describes the error, naming the model if it is known
+func ( unsupportedError *UnsupportedError ) Error() ( string ) {
+	if ( unsupportedError.Model != "" ) {
+		return fmt.Sprintf( "%s is not supported on this model (%s)", unsupportedError.Capability, unsupportedError.Model )
+	}
+
+	return fmt.Sprintf( "%s is not supported on this model", unsupportedError.Capability )
+}
+
+// Matches the sentinel error for unsupported modules, as the device would have answered with that
+func ( unsupportedError *UnsupportedError ) Is( target error ) ( bool ) {
+	return target == ErrModuleNotSupported
+}
@@ -0,0 +1,61 @@
+package kasa_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/viral32111/kasa-smart-plug/source/emulator"
+	"github.com/viral32111/kasa-smart-plug/source/kasa"
+)
+
+// Modules the smart plug does not have must fail with the capability & model before anything is sent to them
+func TestUnsupportedModulesFailEarly( t *testing.T ) {
+	plug, client := newEmulatorClient( t, 0 )
+	plug.Update( func( state *emulator.State ) {
+		state.Model = "HS100(UK)"
+		state.Features = "TIM"
+	} )
+	ctx := context.Background()
+
+	// The energy meter is missing, so each energy usage method must fail without sending anything to it
+	plug.RemoveModule( "emeter" )
+	_, energyError := client.GetEnergyUsage( ctx )
+	expectUnsupported( t, "GetEnergyUsage", energyError, kasa.CapabilityEnergyMeter, "HS100(UK)" )
+	_, dailyError := client.GetDailyUsage( ctx, 2024, time.January )
+	expectUnsupported( t, "GetDailyUsage", dailyError, kasa.CapabilityEnergyMeter, "HS100(UK)" )
+	_, monthlyError := client.GetMonthlyUsage( ctx, 2024 )
+	expectUnsupported( t, "GetMonthlyUsage", monthlyError, kasa.CapabilityEnergyMeter, "HS100(UK)" )
+	_, recentError := client.GetRecentDailyUsage( ctx, 7 )
+	expectUnsupported( t, "GetRecentDailyUsage", recentError, kasa.CapabilityEnergyMeter, "HS100(UK)" )
+
+	// The timers are still there
+	_, _, rulesError := client.GetScheduleRules( ctx )
+	if ( rulesError != nil ) {
+		t.Errorf( "expected the schedule rules, got %v", rulesError )
+	}
+}
+
+// The rule modules must fail with the capability on devices without timers
+func TestRulesRequireTimers( t *testing.T ) {
+	plug, client := newEmulatorClient( t, 0 )
+	plug.Update( func( state *emulator.State ) {
+		state.Features = "ENE"
+	} )
+
+	_, rulesError := client.GetCountdownRules( context.Background() )
+	expectUnsupported( t, "GetCountdownRules", rulesError, kasa.CapabilityTimer, "KP115(UK)" )
+}
+
+// Fails the test unless the error says the model cannot do something
+func expectUnsupported( t *testing.T, name string, actualError error, capability kasa.Capability, model string ) {
+	t.Helper()
+
+	var unsupportedError *kasa.UnsupportedError
+	if ( !errors.As( actualError, &unsupportedError ) || !errors.Is( actualError, kasa.ErrModuleNotSupported ) ) {
+		t.Errorf( "%s: expected an unsupported error, got %v", name, actualError )
+	} else if ( unsupportedError.Capability != capability || unsupportedError.Model != model ) {
+		t.Errorf( "%s: expected %s on the %s, got %s on the %s", name, capability, model, unsupportedError.Capability, unsupportedError.Model )
+	}
+}
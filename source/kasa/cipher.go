@@ -0,0 +1,117 @@
+package kasa
+
+import (
+	"io"
+)
+
+// Encrypts data, usually for sending
+func EncryptData( originalData []byte, initialKey int ) ( []byte ) {
+
+	// Create a byte array to hold the encrypted data
+	encryptedData := make( []byte, len( originalData ) )
+
+	// Encrypt into the byte array, starting from the initial key
+	EncryptBytes( encryptedData, originalData, byte( initialKey ) )
+
+	// Return the byte array containing the encrypted data
+	return encryptedData
+
+}
+
+// Decrypts data, usually for receiving
+func DecryptData( encryptedData []byte, initialKey int ) ( []byte ) {
+
+	// Create a byte array to hold the decrypted data
+	decryptedData := make( []byte, len( encryptedData ) )
+
+	// Decrypt into the byte array, starting from the initial key
+	DecryptBytes( decryptedData, encryptedData, byte( initialKey ) )
+
+	// Return the byte array containing the decrypted data
+	return decryptedData
+
+}
+
+// Encrypts the source into the destination without allocating, which may be the same slice, & returns the key to continue the stream with
+func EncryptBytes( destination []byte, source []byte, key byte ) ( byte ) {
+
+	// Update the key, XOR each byte with the current key, then put it in the destination
+	for index, originalCharacter := range source {
+		key = key ^ originalCharacter
+		destination[ index ] = key
+	}
+
+	// Return the key for the next byte
+	return key
+
+}
+
+// Decrypts the source into the destination without allocating, which may be the same slice, & returns the key to continue the stream with
+func DecryptBytes( destination []byte, source []byte, key byte ) ( byte ) {
+
+	// XOR each byte with the current key, put it in the destination, then update the key
+	for index, encryptedCharacter := range source {
+		destination[ index ] = key ^ encryptedCharacter
+		key = encryptedCharacter
+	}
+
+	// Return the key for the next byte
+	return key
+
+}
+
+// Structure for encrypting everything written to an underlying writer, as one continuous stream
+type EncryptWriter struct {
+	writer io.Writer
+	key byte
+	buffer []byte
+}
+
+// Creates a writer that encrypts into the given writer, starting from the initial key
+func NewEncryptWriter( writer io.Writer, initialKey int ) ( *EncryptWriter ) {
+	return &EncryptWriter{ writer: writer, key: byte( initialKey ) }
+}
+
+// Encrypts the data & writes it to the underlying writer, reusing an internal buffer between writes
+func ( encryptWriter *EncryptWriter ) Write( data []byte ) ( int, error ) {
+
+	// Grow the buffer if this write is larger than any before
+	if ( cap( encryptWriter.buffer ) < len( data ) ) {
+		encryptWriter.buffer = make( []byte, len( data ) )
+	}
+	encryptedData := encryptWriter.buffer[ : len( data ) ]
+
+	// Encrypt the data, without updating the key until it has been written
+	nextKey := EncryptBytes( encryptedData, data, encryptWriter.key )
+
+	// Write the encrypted data, continuing the stream from wherever the write stopped
+	writtenCount, writeError := encryptWriter.writer.Write( encryptedData )
+	if ( writtenCount == len( data ) ) {
+		encryptWriter.key = nextKey
+	} else if ( writtenCount > 0 ) {
+		encryptWriter.key = encryptedData[ writtenCount - 1 ]
+	}
+
+	// Return how much was written
+	return writtenCount, writeError
+
+}
+
+// Structure for decrypting everything read from an underlying reader, as one continuous stream
+type DecryptReader struct {
+	reader io.Reader
+	key byte
+}
+
+// Creates a reader that decrypts from the given reader, starting from the initial key
+func NewDecryptReader( reader io.Reader, initialKey int ) ( *DecryptReader ) {
+	return &DecryptReader{ reader: reader, key: byte( initialKey ) }
+}
+
+// Reads from the underlying reader & decrypts in place, without allocating
+func ( decryptReader *DecryptReader ) Read( data []byte ) ( int, error ) {
+	readCount, readError := decryptReader.reader.Read( data )
+	decryptReader.key = DecryptBytes( data[ : readCount ], data[ : readCount ], decryptReader.key )
+
+	return readCount, readError
+}
@@ -0,0 +1,171 @@
+package kasa
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+// Decrypting must undo encrypting, in place or not & whether the stream is split or whole
+func FuzzDecryptBytes( f *testing.F ) {
+	f.Add( []byte( `{"system":{"get_sysinfo":{}}}` ), byte( DefaultInitialKey ), 5 )
+	f.Add( []byte{}, byte( 0 ), 0 )
+	f.Add( []byte{ 0x00, 0xFF, 0xAB }, byte( 0xFF ), 1 )
+
+	f.Fuzz( func( t *testing.T, data []byte, key byte, split int ) {
+
+		// Decrypting the encrypted data gives the original data
+		encrypted := make( []byte, len( data ) )
+		EncryptBytes( encrypted, data, key )
+		decrypted := make( []byte, len( data ) )
+		DecryptBytes( decrypted, encrypted, key )
+		if ( !bytes.Equal( decrypted, data ) ) {
+			t.Fatalf( "expected %x, got %x", data, decrypted )
+		}
+
+		// Decrypting in place gives the same result
+		inPlace := bytes.Clone( encrypted )
+		DecryptBytes( inPlace, inPlace, key )
+		if ( !bytes.Equal( inPlace, data ) ) {
+			t.Fatalf( "in place: expected %x, got %x", data, inPlace )
+		}
+
+		// Decrypting in two parts, continuing with the returned key, gives the same result
+		if ( split < 0 ) {
+			split = -split
+		}
+		if ( len( data ) > 0 ) {
+			split %= len( data ) + 1
+		} else {
+			split = 0
+		}
+		streamed := make( []byte, len( data ) )
+		nextKey := DecryptBytes( streamed[ : split ], encrypted[ : split ], key )
+		DecryptBytes( streamed[ split : ], encrypted[ split : ], nextKey )
+		if ( !bytes.Equal( streamed, data ) ) {
+			t.Fatalf( "split at %d: expected %x, got %x", split, data, streamed )
+		}
+
+		// Decrypting arbitrary data never panics & keeps the length
+		if ( len( DecryptData( data, int( key ) ) ) != len( data ) ) {
+			t.Fatal( "decrypted length differs" )
+		}
+
+	} )
+}
+
+// Writer that accepts at most a few bytes per write, like a congested connection
+type shortWriter struct {
+	limit int
+	written bytes.Buffer
+}
+
+// Writes up to the limit & reports a short write for the rest
+func ( writer *shortWriter ) Write( data []byte ) ( int, error ) {
+	if ( len( data ) > writer.limit ) {
+		writer.written.Write( data[ : writer.limit ] )
+		return writer.limit, io.ErrShortWrite
+	}
+
+	return writer.written.Write( data )
+}
+
+// Writing in several parts must encrypt the same as all at once, as the key carries over between writes
+func TestEncryptWriterSplitWrites( t *testing.T ) {
+	payload := []byte( `{"system":{"set_relay_state":{"state":1}}}` )
+	expected := EncryptData( payload, DefaultInitialKey )
+
+	for _, chunkSize := range []int{ 1, 2, 3, 7, len( payload ) } {
+		var encrypted bytes.Buffer
+		encryptWriter := NewEncryptWriter( &encrypted, DefaultInitialKey )
+		for offset := 0; offset < len( payload ); offset += chunkSize {
+			_, writeError := encryptWriter.Write( payload[ offset : min( offset + chunkSize, len( payload ) ) ] )
+			if ( writeError != nil ) {
+				t.Fatal( writeError )
+			}
+		}
+
+		if ( !bytes.Equal( encrypted.Bytes(), expected ) ) {
+			t.Errorf( "chunks of %d: expected %x, got %x", chunkSize, expected, encrypted.Bytes() )
+		}
+	}
+}
+
+// Writing the rest again after a short write must continue the stream from where the underlying writer stopped
+func TestEncryptWriterShortWrites( t *testing.T ) {
+	payload := []byte( `{"system":{"get_sysinfo":{}}}` )
+	writer := &shortWriter{ limit: 4 }
+	encryptWriter := NewEncryptWriter( writer, DefaultInitialKey )
+
+	for remaining := payload; len( remaining ) > 0; {
+		writtenCount, writeError := encryptWriter.Write( remaining )
+		if ( writeError != nil && writeError != io.ErrShortWrite ) {
+			t.Fatal( writeError )
+		}
+		remaining = remaining[ writtenCount : ]
+	}
+
+	if ( !bytes.Equal( writer.written.Bytes(), EncryptData( payload, DefaultInitialKey ) ) ) {
+		t.Errorf( "expected the same as encrypting all at once, got %x", writer.written.Bytes() )
+	}
+}
+
+// Reading a byte or a few at a time must decrypt the same as all at once, as the key carries over between reads
+func TestDecryptReaderShortReads( t *testing.T ) {
+	payload := []byte( `{"system":{"get_sysinfo":{"alias":"Kitchen"}}}` )
+	encrypted := EncryptData( payload, DefaultInitialKey )
+
+	for name, reader := range map[string]io.Reader {
+		"one byte": iotest.OneByteReader( bytes.NewReader( encrypted ) ),
+		"half": iotest.HalfReader( bytes.NewReader( encrypted ) ),
+		"whole": bytes.NewReader( encrypted ),
+	} {
+		decrypted, readError := io.ReadAll( NewDecryptReader( reader, DefaultInitialKey ) )
+		if ( readError != nil ) {
+			t.Fatal( readError )
+		}
+		if ( !bytes.Equal( decrypted, payload ) ) {
+			t.Errorf( "%s: expected %q, got %q", name, payload, decrypted )
+		}
+	}
+
+	// Also check the reader with a small buffer rather than io.ReadAll
+	decryptReader := NewDecryptReader( bytes.NewReader( encrypted ), DefaultInitialKey )
+	var decrypted []byte
+	buffer := make( []byte, 5 )
+	for {
+		readCount, readError := decryptReader.Read( buffer )
+		decrypted = append( decrypted, buffer[ : readCount ]... )
+		if ( readError == io.EOF ) {
+			break
+		} else if ( readError != nil ) {
+			t.Fatal( readError )
+		}
+	}
+	if ( !bytes.Equal( decrypted, payload ) ) {
+		t.Errorf( "small buffer: expected %q, got %q", payload, decrypted )
+	}
+}
+
+// Encrypting with the writer & decrypting with the reader must give back the original stream
+func TestEncryptWriterDecryptReaderRoundTrip( t *testing.T ) {
+	payload := bytes.Repeat( []byte( `{"emeter":{"get_realtime":{}}}` ), 50 )
+
+	var stream bytes.Buffer
+	encryptWriter := NewEncryptWriter( &stream, DefaultInitialKey )
+	for offset := 0; offset < len( payload ); offset += 13 {
+		_, writeError := encryptWriter.Write( payload[ offset : min( offset + 13, len( payload ) ) ] )
+		if ( writeError != nil ) {
+			t.Fatal( writeError )
+		}
+	}
+
+	decrypted, readError := io.ReadAll( NewDecryptReader( iotest.HalfReader( &stream ), DefaultInitialKey ) )
+	if ( readError != nil ) {
+		t.Fatal( readError )
+	}
+	if ( !bytes.Equal( decrypted, payload ) ) {
+		t.Error( "round trip did not give back the original stream" )
+	}
+}
@@ -0,0 +1,469 @@
+package kasa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Structure for holding the connection to & methods for a smart plug.
+// It is safe for concurrent use, as queries are sent one at a time over the connection,
+// but the exported fields must not be changed once queries are in progress.
+type Client struct {
+
+	// The IP address & port number of the smart plug's API
+	Address net.IP
+	Port int
+
+	// The initial key for encrypting & decrypting data
+	InitialKey int
+
+	// The maximum time to wait for the connection to open
+	DialTimeout time.Duration
+
+	// The maximum time to wait for each query to be sent & answered, or zero for no limit other than the context
+	Timeout time.Duration
+
+	// How to reconnect & retry when the connection drops
+	Retry RetryPolicy
+
+	// The maximum payload length to accept in a response, or zero for no limit
+	MaxFrameSize int
+
+	// The underlying TCP connection wrapped for encryption & framing, which is opened when needed
+	connection *Conn
+
+	// When the connection was last opened or answered a query, for only checking it is still open after being idle
+	lastUsedAt time.Time
+
+	// Whether the client has been closed, after which no more queries can be sent
+	closed bool
+
+	// Held while using the connection, as a channel so waiting for it can be cancelled
+	lock chan struct{}
+	lockOnce sync.Once
+
+	// The power strip & the identifier of the outlet, if this client is for an outlet
+	parent *Client
+	childIdentifier string
+
+	// What the smart plug can do & its model, remembered from the first system information, as neither change
+	capabilities Capabilities
+	model string
+	capabilitiesMutex sync.Mutex
+
+}
+
+// Creates a client for a smart plug, with the default initial key, timeouts & retry policy
+func NewClient( address net.IP, port int ) ( *Client ) {
+	return &Client{
+		Address: address,
+		Port: port,
+		InitialKey: DefaultInitialKey,
+		DialTimeout: 5 * time.Second,
+		Timeout: 5 * time.Second,
+		Retry: DefaultRetryPolicy(),
+		MaxFrameSize: DefaultMaxFrameSize,
+	}
+}
+
+// Returns a client for an outlet of a power strip, which addresses every query to that outlet.
+// It shares the connection of this client, so connecting or closing either affects both.
+func ( client *Client ) Outlet( childIdentifier string ) ( *Client ) {
+
+	// Always share the connection of the power strip itself
+	parent := client
+	if ( client.parent != nil ) {
+		parent = client.parent
+	}
+
+	// Copy the options, as they are read by callers
+	return &Client{
+		Address: parent.Address,
+		Port: parent.Port,
+		InitialKey: parent.InitialKey,
+		DialTimeout: parent.DialTimeout,
+		Timeout: parent.Timeout,
+		Retry: parent.Retry,
+		MaxFrameSize: parent.MaxFrameSize,
+		parent: parent,
+		childIdentifier: childIdentifier,
+	}
+
+}
+
+// Returns the identifier of the outlet this client is for, or nothing if it is for a whole smart plug
+func ( client *Client ) OutletIdentifier() ( string ) {
+	return client.childIdentifier
+}
+
+// Opens a connection to the smart plug, which is optional as queries connect when needed
+func ( client *Client ) Connect( ctx context.Context ) ( error ) {
+
+	// Use the connection of the power strip, if this is for an outlet
+	if ( client.parent != nil ) {
+		return client.parent.Connect( ctx )
+	}
+
+	// Wait for any query in progress to finish
+	acquireError := client.acquire( ctx )
+	if ( acquireError != nil ) {
+		return acquireError
+	}
+	defer client.release()
+
+	// Allow queries again if the client was closed
+	client.closed = false
+
+	// Open the connection
+	return client.connect( ctx )
+
+}
+
+// Opens a connection to the smart plug, replacing any existing connection, while the lock is held
+func ( client *Client ) connect( ctx context.Context ) ( error ) {
+
+	// Close any existing connection
+	if ( client.connection != nil ) {
+		client.connection.Close()
+		client.connection = nil
+	}
+
+	// Try to open a TCP connection to the smart plug
+	dialer := net.Dialer{ Timeout: client.DialTimeout }
+	connection, connectError := dialer.DialContext( ctx, "tcp4", net.JoinHostPort( client.Address.String(), strconv.Itoa( client.Port ) ) )
+	if ( connectError != nil ) {
+		return asTimeoutError( ctx, connectError )
+	}
+
+	// Set the connection on the client, wrapped for encryption & framing
+	client.connection = NewConn( connection, client.InitialKey )
+	client.connection.SetMaxFrameSize( client.MaxFrameSize )
+	client.lastUsedAt = time.Now()
+
+	// Return no error
+	return nil
+
+}
+
+// Closes the connection with the smart plug, waiting for any query in progress to finish
+func ( client *Client ) Close() ( error ) {
+
+	// Use the connection of the power strip, if this is for an outlet
+	if ( client.parent != nil ) {
+		return client.parent.Close()
+	}
+
+	// Wait for any query in progress to finish
+	client.acquire( context.Background() )
+	defer client.release()
+
+	// Prevent any more queries
+	client.closed = true
+
+	// Nothing to do if we are not connected
+	if ( client.connection == nil ) {
+		return nil
+	}
+
+	// Try to close the connection
+	closeError := client.connection.Close()
+	client.connection = nil
+	if ( closeError != nil ) {
+		return closeError
+	}
+
+	// Return no error
+	return nil
+
+}
+
+// Sends a query for a single method to the smart plug, parsing the response into the response structure, or returning a device error if the method failed
+func ( client *Client ) SendQuery( ctx context.Context, moduleName string, methodName string, arguments any ) ( QueryResponse, error ) {
+
+	// Create the JSON payload containing the query
+	request := NewRequest().Add( moduleName, methodName, arguments )
+	jsonPayload, encodeError := client.encode( request )
+	if ( encodeError != nil ) {
+		return QueryResponse{}, encodeError
+	}
+
+	// Send the payload & wait for the response
+	responsePayload, roundTripError := client.roundTrip( ctx, jsonPayload, request.IsReadOnly() )
+	if ( roundTripError != nil ) {
+		return QueryResponse{}, roundTripError
+	}
+
+	// Fail if the smart plug set an error for the method, or for the whole module
+	response, parseError := parseResponse( request, responsePayload )
+	if ( parseError != nil ) {
+		return QueryResponse{}, parseError
+	}
+	deviceError := response.results[ 0 ].Err()
+	if ( deviceError != nil ) {
+		return QueryResponse{}, deviceError
+	}
+
+	// Parse the response payload as JSON into the response structure
+	var queryResponse QueryResponse
+	decodeError := json.Unmarshal( responsePayload, &queryResponse )
+	if ( decodeError != nil ) {
+		return QueryResponse{}, fmt.Errorf( "%w: %w", ErrInvalidResponse, decodeError )
+	}
+
+	// Return the response
+	return queryResponse, nil
+
+}
+
+// Encodes a request as JSON, addressing it to the outlet if this client is for one & the request does not say otherwise
+func ( client *Client ) encode( request *Request ) ( []byte, error ) {
+	if ( client.childIdentifier != "" && len( request.childIdentifiers ) == 0 ) {
+		outletRequest := *request
+		outletRequest.childIdentifiers = []string{ client.childIdentifier }
+
+		return json.Marshal( &outletRequest )
+	}
+
+	return json.Marshal( request )
+}
+
+// Sends a query for a single method to the smart plug, parsing its result into the given value (nil to ignore it), or returning a device error if the method failed
+func ( client *Client ) call( ctx context.Context, moduleName string, methodName string, arguments any, result any ) ( error ) {
+
+	// Send the query
+	response, sendError := client.Send( ctx, NewRequest().Add( moduleName, methodName, arguments ) )
+	if ( sendError != nil ) {
+		return sendError
+	}
+
+	// Parse the result
+	return response.Decode( moduleName, methodName, result )
+
+}
+
+// Sends a request of one or more methods to the smart plug in a single frame, returning the result of each method
+func ( client *Client ) Send( ctx context.Context, request *Request ) ( Response, error ) {
+
+	// Create the JSON payload containing the request
+	jsonPayload, encodeError := client.encode( request )
+	if ( encodeError != nil ) {
+		return Response{}, encodeError
+	}
+
+	// Send the payload & wait for the response
+	responsePayload, roundTripError := client.roundTrip( ctx, jsonPayload, request.IsReadOnly() )
+	if ( roundTripError != nil ) {
+		return Response{}, roundTripError
+	}
+
+	// Split the response payload into the result of each method
+	return parseResponse( request, responsePayload )
+
+}
+
+// Sends a JSON payload to the smart plug & returns the JSON payload of the response, reconnecting & retrying if the connection drops
+func ( client *Client ) roundTrip( ctx context.Context, jsonPayload []byte, isReadOnly bool ) ( []byte, error ) {
+
+	// Use the connection of the power strip, if this is for an outlet
+	if ( client.parent != nil ) {
+		return client.parent.roundTrip( ctx, jsonPayload, isReadOnly )
+	}
+
+	// Wait for any other query to finish, as responses would be interleaved on the connection
+	acquireError := client.acquire( ctx )
+	if ( acquireError != nil ) {
+		return nil, acquireError
+	}
+	defer client.release()
+
+	// Fail if the client has been closed
+	if ( client.closed ) {
+		return nil, ErrNotConnected
+	}
+
+	// Only retry queries that are safe to repeat, unless the caller opted in
+	canResend := ( isReadOnly || client.Retry.RetryMutations )
+
+	// The error from the last time the payload was sent, if it has been sent yet
+	var lastAttemptError error
+
+	for attempt := 1; ; attempt++ {
+
+		// Fail if the context is finished
+		contextError := ctx.Err()
+		if ( contextError != nil ) {
+			return nil, asTimeoutError( ctx, contextError )
+		}
+
+		// Redial if the connection was never opened or has dropped while idle, which is always safe to retry as nothing has been sent yet
+		if ( client.connection == nil || ( time.Since( client.lastUsedAt ) >= idleCheckAfter && !isConnectionAlive( client.connection.Conn ) ) ) {
+			connectError := client.connect( ctx )
+			if ( connectError != nil ) {
+				if ( isConnectionError( connectError ) && attempt < client.Retry.MaxAttempts ) {
+					waitError := client.Retry.wait( ctx, attempt )
+					if ( waitError != nil ) {
+						return nil, waitError
+					}
+
+					continue
+				}
+
+				// Keep the error from resending, as the query may have been applied before the connection dropped
+				return nil, errors.Join( lastAttemptError, connectError )
+			}
+		}
+
+		// Send the payload & wait for the response
+		responsePayload, attemptError := client.attempt( ctx, jsonPayload )
+		if ( attemptError == nil ) {
+			return responsePayload, nil
+		}
+		lastAttemptError = attemptError
+
+		// Give up if the query may have already been applied, or if there are no attempts left
+		if ( !canResend || !isConnectionError( attemptError ) || attempt >= client.Retry.MaxAttempts ) {
+			return nil, attemptError
+		}
+
+		// Wait before trying again
+		waitError := client.Retry.wait( ctx, attempt )
+		if ( waitError != nil ) {
+			return nil, waitError
+		}
+
+	}
+
+}
+
+// Sends a JSON payload once over the current connection & returns the JSON payload of the response
+func ( client *Client ) attempt( ctx context.Context, jsonPayload []byte ) ( []byte, error ) {
+
+	// Give up once the timeout passes, or earlier if the context has a sooner deadline
+	deadline := time.Time{}
+	if ( client.Timeout > 0 ) {
+		deadline = time.Now().Add( client.Timeout )
+	}
+	contextDeadline, hasDeadline := ctx.Deadline()
+	if ( hasDeadline && ( deadline.IsZero() || contextDeadline.Before( deadline ) ) ) {
+		deadline = contextDeadline
+	}
+	deadlineError := client.connection.SetDeadline( deadline )
+	if ( deadlineError != nil ) {
+		return nil, deadlineError
+	}
+
+	// Unblock the reads & writes if the context is cancelled part-way through
+	connection := client.connection
+	stopWatching := context.AfterFunc( ctx, func() {
+		connection.SetDeadline( time.Now() )
+	} )
+	defer stopWatching()
+
+	// Exchange the payloads
+	responsePayload, isSent, exchangeError := client.exchange( jsonPayload )
+	if ( exchangeError != nil ) {
+
+		// The connection is in an unknown state part-way through a frame, so it cannot be used again
+		client.connection.Close()
+		client.connection = nil
+
+		// Mark when the payload was sent but the connection then dropped or went quiet, as the query may have been applied
+		timeoutError := asTimeoutError( ctx, exchangeError )
+		if ( isSent && ( isConnectionError( timeoutError ) || errors.Is( timeoutError, ErrTimeout ) ) ) {
+			return nil, fmt.Errorf( "%w: %w", ErrNoReply, timeoutError )
+		}
+
+		return nil, timeoutError
+
+	}
+
+	// Clear the deadline for the next query
+	clearDeadlineError := client.connection.SetDeadline( time.Time{} )
+	if ( clearDeadlineError != nil ) {
+		return nil, clearDeadlineError
+	}
+	client.lastUsedAt = time.Now()
+
+	// Return the response payload
+	return responsePayload, nil
+
+}
+
+// Writes the encrypted & length-prefixed payload then reads the response, relying on the caller to set deadlines.
+// Also returns whether the payload was written, so a failure while reading can be told apart from one while sending.
+func ( client *Client ) exchange( jsonPayload []byte ) ( []byte, bool, error ) {
+
+	// Send the payload as a single frame
+	writeError := client.connection.WriteFrame( jsonPayload )
+	if ( writeError != nil ) {
+		return nil, false, writeError
+	}
+
+	// Read the response frame into a new buffer, as the payload is parsed after the lock is released
+	responsePayload, readError := client.connection.ReadFrame( nil )
+	if ( readError != nil ) {
+		return nil, true, readError
+	}
+
+	// Return the response payload
+	return responsePayload, true, nil
+
+}
+
+// Converts network timeouts & finished contexts into the timeout error, leaving other errors as they are
+func asTimeoutError( ctx context.Context, originalError error ) ( error ) {
+
+	// The context was cancelled by the caller, which is not a timeout
+	if ( errors.Is( ctx.Err(), context.Canceled ) ) {
+		return ctx.Err()
+	}
+
+	// The deadline of the context passed
+	if ( errors.Is( ctx.Err(), context.DeadlineExceeded ) ) {
+		return fmt.Errorf( "%w: %w", ErrTimeout, ctx.Err() )
+	}
+
+	// The timeout of the client passed, or the deadline of the context passed just before the context noticed
+	var networkError net.Error
+	if ( errors.As( originalError, &networkError ) && networkError.Timeout() ) {
+		contextDeadline, hasDeadline := ctx.Deadline()
+		if ( hasDeadline && !time.Now().Before( contextDeadline ) ) {
+			return fmt.Errorf( "%w: %w", ErrTimeout, context.DeadlineExceeded )
+		}
+
+		return fmt.Errorf( "%w: %w", ErrTimeout, originalError )
+	}
+
+	// Otherwise, this is not a timeout
+	return originalError
+
+}
+
+// Waits to hold the lock for using the connection, or until the context is finished
+func ( client *Client ) acquire( ctx context.Context ) ( error ) {
+
+	// Create the lock on first use, so a client created without the constructor still works
+	client.lockOnce.Do( func() {
+		client.lock = make( chan struct{}, 1 )
+	} )
+
+	// Wait for the lock or the context, whichever comes first
+	select {
+		case client.lock <- struct{}{}:
+			return nil
+		case <-ctx.Done():
+			return asTimeoutError( ctx, ctx.Err() )
+	}
+
+}
+
+// Releases the lock for using the connection
+func ( client *Client ) release() {
+	<-client.lock
+}
@@ -0,0 +1,239 @@
+package kasa_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/viral32111/kasa-smart-plug/source/emulator"
+	"github.com/viral32111/kasa-smart-plug/source/kasa"
+)
+
+// Starts a fake smart plug, or a power strip if there are outlets, on a random port & returns a client for it, both closed when the test finishes
+func newEmulatorClient( t *testing.T, outlets int ) ( *emulator.Plug, *kasa.Client ) {
+	plug := emulator.New()
+	if ( outlets > 0 ) {
+		plug.Update( func( state *emulator.State ) {
+			*state = emulator.DefaultStripState( outlets )
+		} )
+	}
+
+	listenError := plug.Listen( "127.0.0.1:0" )
+	if ( listenError != nil ) {
+		t.Fatal( listenError )
+	}
+	t.Cleanup( func() { plug.Close() } )
+
+	client := kasa.NewClient( plug.Address(), plug.Port() )
+	t.Cleanup( func() { client.Close() } )
+
+	return plug, client
+}
+
+// Queries from many goroutines on one client, & on outlet clients sharing its connection, must not interleave or race
+func TestClientConcurrentQueries( t *testing.T ) {
+	plug, client := newEmulatorClient( t, 3 )
+	ctx := context.Background()
+
+	// Find the outlets to address
+	outlets, outletsError := client.GetOutlets( ctx )
+	if ( outletsError != nil ) {
+		t.Fatal( outletsError )
+	}
+	if ( len( outlets ) != 3 ) {
+		t.Fatalf( "expected 3 outlets, got %d", len( outlets ) )
+	}
+
+	// Hammer the power strip & each outlet at the same time
+	var waitGroup sync.WaitGroup
+	errorsChannel := make( chan error, 1000 )
+	for worker := 0; worker < 16; worker++ {
+		waitGroup.Add( 1 )
+		go func( worker int ) {
+			defer waitGroup.Done()
+
+			// Alternate between the whole power strip & one of its outlets
+			target := client
+			if ( worker % 2 == 1 ) {
+				target = client.Outlet( outlets[ worker % len( outlets ) ].Identifier )
+			}
+
+			for iteration := 0; iteration < 20; iteration++ {
+				_, propertiesError := target.GetProperties( ctx )
+				if ( propertiesError != nil ) {
+					errorsChannel <- propertiesError
+				}
+
+				setError := target.SetPowerState( ctx, iteration % 2 == 0 )
+				if ( setError != nil ) {
+					errorsChannel <- setError
+				}
+			}
+		}( worker )
+	}
+	waitGroup.Wait()
+	close( errorsChannel )
+
+	// Fail on any error
+	for queryError := range errorsChannel {
+		t.Error( queryError )
+	}
+
+	// Every worker finished by switching off, so the outlets that were used must be off
+	state := plug.State()
+	for _, child := range state.Children {
+		if ( child.RelayState ) {
+			t.Errorf( "expected outlet '%s' to be off", child.Alias )
+		}
+	}
+}
+
+// Closing the client while queries are in progress must not race, & queries afterwards must fail
+func TestClientCloseDuringQueries( t *testing.T ) {
+	_, client := newEmulatorClient( t, 2 )
+	ctx := context.Background()
+
+	var waitGroup sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		waitGroup.Add( 1 )
+		go func() {
+			defer waitGroup.Done()
+
+			for iteration := 0; iteration < 10; iteration++ {
+				client.GetSystemInformation( ctx )
+			}
+		}()
+	}
+	client.Close()
+	waitGroup.Wait()
+
+	_, queryError := client.GetSystemInformation( ctx )
+	if ( queryError == nil ) {
+		t.Error( "expected an error after closing" )
+	}
+}
+
+// Reads sent on a connection that dropped between queries must be retried on a new connection
+func TestClientRetriesReadAfterDropBetweenQueries( t *testing.T ) {
+	plug, client := newEmulatorClient( t, 0 )
+	client.Retry.InitialBackoff = 10 * time.Millisecond
+	ctx := context.Background()
+
+	_, firstError := client.GetSystemInformation( ctx )
+	if ( firstError != nil ) {
+		t.Fatal( firstError )
+	}
+
+	// Drop the connection straight away, before the client would check it is still open
+	plug.DropConnections()
+	_, secondError := client.GetSystemInformation( ctx )
+	if ( secondError != nil ) {
+		t.Errorf( "expected the read to be retried, got %v", secondError )
+	}
+}
+
+// Changes sent after the connection dropped while idle must go over a new connection
+func TestClientReconnectsAfterIdleDrop( t *testing.T ) {
+	plug, client := newEmulatorClient( t, 0 )
+	ctx := context.Background()
+
+	_, firstError := client.GetSystemInformation( ctx )
+	if ( firstError != nil ) {
+		t.Fatal( firstError )
+	}
+
+	// Drop the connection & stay idle long enough for the client to check it before sending
+	plug.DropConnections()
+	time.Sleep( 1100 * time.Millisecond )
+	setError := client.SetPowerState( ctx, false )
+	if ( setError != nil ) {
+		t.Errorf( "expected the change to be sent over a new connection, got %v", setError )
+	}
+	if ( plug.State().RelayState ) {
+		t.Error( "expected the smart plug to be switched off" )
+	}
+}
+
+// Reads must be resent if the connection drops part-way through the query
+func TestClientRetriesReadAfterDropMidQuery( t *testing.T ) {
+	plug, client := newEmulatorClient( t, 0 )
+	client.Retry.InitialBackoff = 10 * time.Millisecond
+	ctx := context.Background()
+
+	// Drop the connection the first time, without answering
+	var calls atomic.Int32
+	plug.Handle( "netif", "get_scaninfo", func( state *emulator.State, arguments json.RawMessage ) ( map[string]any, error ) {
+		if ( calls.Add( 1 ) == 1 ) {
+			plug.DropConnections()
+		}
+
+		return map[string]any{ "ap_list": state.AccessPoints }, nil
+	} )
+
+	accessPoints, scanError := client.ScanWiFi( ctx, false )
+	if ( scanError != nil ) {
+		t.Fatalf( "expected the read to be retried, got %v", scanError )
+	}
+	if ( calls.Load() != 2 || len( accessPoints ) == 0 ) {
+		t.Errorf( "expected 2 calls & the access points, got %d calls & %v", calls.Load(), accessPoints )
+	}
+}
+
+// Changes must never be resent if the connection drops part-way through the query, as they may have been applied
+func TestClientDoesNotResendChangeAfterDropMidQuery( t *testing.T ) {
+	plug, client := newEmulatorClient( t, 0 )
+	client.Retry.InitialBackoff = 10 * time.Millisecond
+	ctx := context.Background()
+
+	// Apply the change but drop the connection without answering
+	var calls atomic.Int32
+	plug.Handle( "system", "set_relay_state", func( state *emulator.State, arguments json.RawMessage ) ( map[string]any, error ) {
+		calls.Add( 1 )
+		state.RelayState = false
+		plug.DropConnections()
+
+		return nil, nil
+	} )
+
+	setError := client.SetPowerState( ctx, false )
+	if ( !errors.Is( setError, kasa.ErrNoReply ) ) {
+		t.Errorf( "expected no reply, got %v", setError )
+	}
+	if ( calls.Load() != 1 ) {
+		t.Errorf( "expected the change to be sent once, got %d", calls.Load() )
+	}
+
+	// The next query must reconnect
+	_, infoError := client.GetSystemInformation( ctx )
+	if ( infoError != nil ) {
+		t.Errorf( "expected a new connection, got %v", infoError )
+	}
+}
+
+// Power strips that only measure each outlet must report the total of the outlets, rather than failing
+func TestPropertiesSumOutletsWithoutStripEnergyMeter( t *testing.T ) {
+	plug, client := newEmulatorClient( t, 3 )
+
+	// Only answer the energy meter for the outlets, like the HS300
+	plug.Handle( "emeter", "get_realtime", func( state *emulator.State, arguments json.RawMessage ) ( map[string]any, error ) {
+		return nil, &kasa.DeviceError{ Code: kasa.ErrorCodeModuleNotSupported, Message: "module not support" }
+	} )
+
+	smartPlug, propertiesError := client.GetProperties( context.Background() )
+	if ( propertiesError != nil ) {
+		t.Fatal( propertiesError )
+	}
+
+	// Check the power strip reports the total of its outlets
+	expectedWattage := 0.0
+	for _, outlet := range smartPlug.Outlets {
+		expectedWattage += outlet.Energy.Wattage
+	}
+	if ( expectedWattage == 0 || smartPlug.Energy.Wattage != expectedWattage ) {
+		t.Errorf( "expected the outlets to add up to %.2f W, got %.2f W", expectedWattage, smartPlug.Energy.Wattage )
+	}
+}
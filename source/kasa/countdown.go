@@ -0,0 +1,148 @@
+package kasa
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Structure for holding a rule that switches the power relay on or off once a delay has passed
+type CountdownRule struct {
+	Identifier string // Assigned by the smart plug
+	Name string
+	Enabled bool
+
+	// How long to wait before switching, & how long is left (only set by the smart plug)
+	Delay time.Duration
+	Remaining time.Duration
+
+	// Whether to switch on or off
+	PowerState bool
+}
+
+// Structure for a countdown rule as sent & received by the smart plug
+type countdownRuleJSON struct {
+	Identifier string `json:"id,omitempty"`
+	Name string `json:"name"`
+	Enable int `json:"enable"`
+	Delay int `json:"delay"` // seconds
+	Action int `json:"act"`
+	Remaining int `json:"remain,omitempty"` // seconds
+}
+
+// Checks the rule can be sent to the smart plug, which only counts down in whole seconds
+func ( rule CountdownRule ) validate() ( error ) {
+	if ( rule.Delay < time.Second ) {
+		return errors.New( "countdown rule delay must be at least 1 second" )
+	}
+
+	return nil
+}
+
+// Converts the rule to how the smart plug expects it
+func ( rule CountdownRule ) toJSON() ( countdownRuleJSON ) {
+	return countdownRuleJSON{
+		Identifier: rule.Identifier,
+		Name: rule.Name,
+		Enable: boolToInt( rule.Enabled ),
+		Delay: int( rule.Delay / time.Second ),
+		Action: boolToInt( rule.PowerState ),
+	}
+}
+
+// Converts the rule from how the smart plug sent it
+func ( ruleJSON countdownRuleJSON ) toRule() ( CountdownRule ) {
+	return CountdownRule{
+		Identifier: ruleJSON.Identifier,
+		Name: ruleJSON.Name,
+		Enabled: ( ruleJSON.Enable != 0 ),
+		Delay: time.Duration( ruleJSON.Delay ) * time.Second,
+		Remaining: time.Duration( ruleJSON.Remaining ) * time.Second,
+		PowerState: ( ruleJSON.Action == 1 ),
+	}
+}
+
+// Get the countdown rules
+func ( client *Client ) GetCountdownRules( ctx context.Context ) ( []CountdownRule, error ) {
+
+	// Fetch the rules
+	var rulesJSON []countdownRuleJSON
+	_, rulesError := client.getRules( ctx, "count_down", &rulesJSON )
+	if ( rulesError != nil ) {
+		return nil, rulesError
+	}
+
+	// Convert each rule
+	rules := make( []CountdownRule, len( rulesJSON ) )
+	for index, ruleJSON := range rulesJSON {
+		rules[ index ] = ruleJSON.toRule()
+	}
+
+	// Return the rules
+	return rules, nil
+
+}
+
+// Adds a countdown rule, which starts counting down straight away if enabled, & returns the identifier that the smart plug assigned to it.
+// Most smart plugs only hold one countdown rule, so remove any existing rules first.
+func ( client *Client ) AddCountdownRule( ctx context.Context, rule CountdownRule ) ( string, error ) {
+
+	// Fail if the rule is invalid
+	validateError := rule.validate()
+	if ( validateError != nil ) {
+		return "", validateError
+	}
+
+	// Add the rule, without an identifier
+	rule.Identifier = ""
+	return client.addRule( ctx, "count_down", rule.toJSON() )
+
+}
+
+// Replaces an existing countdown rule with the same identifier, which restarts the countdown
+func ( client *Client ) EditCountdownRule( ctx context.Context, rule CountdownRule ) ( error ) {
+
+	// Fail if the rule is invalid or does not say which rule to replace
+	if ( rule.Identifier == "" ) {
+		return errors.New( "countdown rule has no identifier" )
+	}
+	validateError := rule.validate()
+	if ( validateError != nil ) {
+		return validateError
+	}
+
+	// Replace the rule
+	return client.editRule( ctx, "count_down", rule.toJSON() )
+
+}
+
+// Removes a countdown rule
+func ( client *Client ) DeleteCountdownRule( ctx context.Context, identifier string ) ( error ) {
+	return client.deleteRule( ctx, "count_down", identifier )
+}
+
+// Removes every countdown rule, cancelling any countdown in progress
+func ( client *Client ) DeleteAllCountdownRules( ctx context.Context ) ( error ) {
+	return client.deleteAllRules( ctx, "count_down" )
+}
+
+// Get the countdown that is in progress, if there is one
+func ( client *Client ) GetActiveCountdown( ctx context.Context ) ( CountdownRule, bool, error ) {
+
+	// Fetch the rules
+	rules, rulesError := client.GetCountdownRules( ctx )
+	if ( rulesError != nil ) {
+		return CountdownRule{}, false, rulesError
+	}
+
+	// Use the first enabled rule that has time left
+	for _, rule := range rules {
+		if ( rule.Enabled && rule.Remaining > 0 ) {
+			return rule, true, nil
+		}
+	}
+
+	// No countdown is in progress
+	return CountdownRule{}, false, nil
+
+}
@@ -0,0 +1,275 @@
+package kasa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// The module that dimmer switches, such as the HS220, use for the brightness
+const dimmerModule = "smartlife.iot.dimmer"
+
+// What a dimmer switch does when its button is double-clicked or long-pressed
+const (
+	DimmerActionNone = "none"
+	DimmerActionInstant = "instant_on_off"
+	DimmerActionGentle = "gentle_on_off"
+	DimmerActionPreset = "customize_preset"
+)
+
+// Structure for holding a snapshot of the data about a dimmer switch, which is otherwise the same as a smart plug
+type SmartDimmer struct {
+	SmartPlug
+
+	// The brightness the light is switched on at, in percent
+	Brightness int
+
+	// How the dimmer switch fades the light
+	Parameters DimmerParameters
+
+	// What the button does
+	DoubleClick DimmerAction
+	LongPress DimmerAction
+}
+
+// Structure for holding how a dimmer switch fades the light
+type DimmerParameters struct {
+
+	// The lowest brightness the light is dimmed to, in percent
+	MinimumThreshold int
+
+	// How long it takes to fade on & off, when switched normally or gently
+	FadeOnTime time.Duration
+	FadeOffTime time.Duration
+	GentleOnTime time.Duration
+	GentleOffTime time.Duration
+
+	// How quickly the brightness changes while the button is held
+	RampRate int
+
+}
+
+// Structure for holding what a dimmer switch does when its button is double-clicked or long-pressed
+type DimmerAction struct {
+	Mode string
+
+	// The position of the brightness preset, only for the customise preset mode
+	PresetIndex int
+}
+
+// Structure for the parameters as sent by the dimmer switch, with times in milliseconds
+type dimmerParametersJSON struct {
+	MinimumThreshold int `json:"minThreshold"`
+	FadeOnTime int `json:"fadeOnTime"`
+	FadeOffTime int `json:"fadeOffTime"`
+	GentleOnTime int `json:"gentleOnTime"`
+	GentleOffTime int `json:"gentleOffTime"`
+	RampRate int `json:"rampRate"`
+}
+
+// Structure for a button action as sent & received by the dimmer switch
+type dimmerActionJSON struct {
+	Mode string `json:"mode"`
+	Index *int `json:"index,omitempty"`
+}
+
+// Converts the button action to how the dimmer switch expects it
+func ( action DimmerAction ) toJSON() ( dimmerActionJSON ) {
+	if ( action.Mode == DimmerActionPreset ) {
+		return dimmerActionJSON{ Mode: action.Mode, Index: &action.PresetIndex }
+	}
+
+	return dimmerActionJSON{ Mode: action.Mode }
+}
+
+// Converts the button action from how the dimmer switch sent it
+func ( actionJSON dimmerActionJSON ) toAction() ( DimmerAction ) {
+	action := DimmerAction{ Mode: actionJSON.Mode }
+	if ( actionJSON.Index != nil ) {
+		action.PresetIndex = *actionJSON.Index
+	}
+
+	return action
+}
+
+// Checks the button action can be sent to the dimmer switch
+func ( action DimmerAction ) validate() ( error ) {
+	if ( action.Mode != DimmerActionNone && action.Mode != DimmerActionInstant && action.Mode != DimmerActionGentle && action.Mode != DimmerActionPreset ) {
+		return fmt.Errorf( "unknown dimmer action mode '%s'", action.Mode )
+	}
+	if ( action.PresetIndex < 0 ) {
+		return fmt.Errorf( "invalid dimmer preset index %d", action.PresetIndex )
+	}
+
+	return nil
+}
+
+// Fetches the system information, brightness, parameters & button actions of a dimmer switch
+func ( client *Client ) GetDimmerProperties( ctx context.Context ) ( SmartDimmer, error ) {
+
+	// Fetch the system information, which includes the brightness
+	queryResponse, sendError := client.SendQuery( ctx, "system", "get_sysinfo", nil )
+	if ( sendError != nil ) {
+		return SmartDimmer{}, sendError
+	}
+	smartPlug, infoError := client.smartPlugFromResponse( queryResponse )
+	if ( infoError != nil ) {
+		return SmartDimmer{}, infoError
+	}
+
+	// Fetch the parameters
+	parameters, parametersError := client.GetDimmerParameters( ctx )
+	if ( parametersError != nil ) {
+		return SmartDimmer{}, parametersError
+	}
+
+	// Fetch the button actions
+	doubleClick, longPress, actionsError := client.GetDimmerActions( ctx )
+	if ( actionsError != nil ) {
+		return SmartDimmer{}, actionsError
+	}
+
+	// Return the populated snapshot
+	return SmartDimmer{
+		SmartPlug: smartPlug,
+		Brightness: queryResponse.System.Info.Brightness,
+		Parameters: parameters,
+		DoubleClick: doubleClick,
+		LongPress: longPress,
+	}, nil
+
+}
+
+// Sets the brightness of a dimmer switch straight away, between 1 & 100 percent
+func ( client *Client ) SetBrightness( ctx context.Context, brightness int ) ( error ) {
+
+	// Require a brightness the dimmer switch accepts
+	if ( brightness < 1 || brightness > 100 ) {
+		return fmt.Errorf( "invalid brightness %d, must be between 1 and 100", brightness )
+	}
+
+	// Send the brightness command
+	return client.call( ctx, dimmerModule, "set_brightness", map[string]int {
+		"brightness": brightness,
+	}, nil )
+
+}
+
+// Fades the brightness of a dimmer switch over the given duration, switching it on if needed
+func ( client *Client ) FadeBrightness( ctx context.Context, brightness int, duration time.Duration ) ( error ) {
+
+	// Require a brightness & duration the dimmer switch accepts
+	if ( brightness < 1 || brightness > 100 ) {
+		return fmt.Errorf( "invalid brightness %d, must be between 1 and 100", brightness )
+	}
+	if ( duration < 0 ) {
+		return errors.New( "fade duration cannot be negative" )
+	}
+
+	// Send the transition command, which takes milliseconds
+	return client.call( ctx, dimmerModule, "set_dimmer_transition", map[string]int {
+		"brightness": brightness,
+		"duration": int( duration / time.Millisecond ),
+	}, nil )
+
+}
+
+// Get how a dimmer switch fades the light
+func ( client *Client ) GetDimmerParameters( ctx context.Context ) ( DimmerParameters, error ) {
+
+	// Fetch the parameters
+	var parametersJSON dimmerParametersJSON
+	parametersError := client.call( ctx, dimmerModule, "get_dimmer_parameters", nil, &parametersJSON )
+	if ( parametersError != nil ) {
+		return DimmerParameters{}, parametersError
+	}
+
+	// Convert the times from milliseconds & return the parameters
+	return DimmerParameters{
+		MinimumThreshold: parametersJSON.MinimumThreshold,
+		FadeOnTime: time.Duration( parametersJSON.FadeOnTime ) * time.Millisecond,
+		FadeOffTime: time.Duration( parametersJSON.FadeOffTime ) * time.Millisecond,
+		GentleOnTime: time.Duration( parametersJSON.GentleOnTime ) * time.Millisecond,
+		GentleOffTime: time.Duration( parametersJSON.GentleOffTime ) * time.Millisecond,
+		RampRate: parametersJSON.RampRate,
+	}, nil
+
+}
+
+// Sets how long a dimmer switch takes to fade on when switched normally
+func ( client *Client ) SetFadeOnTime( ctx context.Context, duration time.Duration ) ( error ) {
+	return client.setDimmerTime( ctx, "set_fade_on_time", "fadeTime", duration )
+}
+
+// Sets how long a dimmer switch takes to fade off when switched normally
+func ( client *Client ) SetFadeOffTime( ctx context.Context, duration time.Duration ) ( error ) {
+	return client.setDimmerTime( ctx, "set_fade_off_time", "fadeTime", duration )
+}
+
+// Sets how long a dimmer switch takes to fade on when switched gently
+func ( client *Client ) SetGentleOnTime( ctx context.Context, duration time.Duration ) ( error ) {
+	return client.setDimmerTime( ctx, "set_gentle_on_time", "duration", duration )
+}
+
+// Sets how long a dimmer switch takes to fade off when switched gently
+func ( client *Client ) SetGentleOffTime( ctx context.Context, duration time.Duration ) ( error ) {
+	return client.setDimmerTime( ctx, "set_gentle_off_time", "duration", duration )
+}
+
+// Sends one of the fade time commands, which take milliseconds
+func ( client *Client ) setDimmerTime( ctx context.Context, methodName string, argumentName string, duration time.Duration ) ( error ) {
+	if ( duration < 0 ) {
+		return errors.New( "fade time cannot be negative" )
+	}
+
+	return client.call( ctx, dimmerModule, methodName, map[string]int {
+		argumentName: int( duration / time.Millisecond ),
+	}, nil )
+}
+
+// Get what a dimmer switch does when its button is double-clicked & long-pressed
+func ( client *Client ) GetDimmerActions( ctx context.Context ) ( DimmerAction, DimmerAction, error ) {
+
+	// Fetch the default behaviour, which includes the button actions
+	var behaviour struct {
+		DoubleClick dimmerActionJSON `json:"double_click"`
+		LongPress dimmerActionJSON `json:"long_press"`
+	}
+	behaviourError := client.call( ctx, dimmerModule, "get_default_behavior", nil, &behaviour )
+	if ( behaviourError != nil ) {
+		return DimmerAction{}, DimmerAction{}, behaviourError
+	}
+
+	// Return both actions
+	return behaviour.DoubleClick.toAction(), behaviour.LongPress.toAction(), nil
+
+}
+
+// Sets what a dimmer switch does when its button is double-clicked
+func ( client *Client ) SetDoubleClickAction( ctx context.Context, action DimmerAction ) ( error ) {
+
+	// Fail if the action is invalid
+	validateError := action.validate()
+	if ( validateError != nil ) {
+		return validateError
+	}
+
+	// Send the action command
+	return client.call( ctx, dimmerModule, "set_double_click_action", action.toJSON(), nil )
+
+}
+
+// Sets what a dimmer switch does when its button is long-pressed
+func ( client *Client ) SetLongPressAction( ctx context.Context, action DimmerAction ) ( error ) {
+
+	// Fail if the action is invalid
+	validateError := action.validate()
+	if ( validateError != nil ) {
+		return validateError
+	}
+
+	// Send the action command
+	return client.call( ctx, dimmerModule, "set_long_press_action", action.toJSON(), nil )
+
+}
@@ -0,0 +1,143 @@
+package kasa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"time"
+)
+
+// Structure for holding data about a smart plug that responded to discovery
+type DiscoveredDevice struct {
+	Address net.IP `json:"address"`
+	MACAddress string `json:"mac"`
+	Alias string `json:"alias"`
+	Model string `json:"model"`
+	DeviceIdentifier string `json:"deviceId"`
+}
+
+// Structure for holding the options for discovering smart plugs
+type Discovery struct {
+
+	// The address & port number to broadcast the query to
+	BroadcastAddress net.IP
+	Port int
+
+	// The initial key for encrypting & decrypting data
+	InitialKey int
+
+	// How long to listen for responses after broadcasting
+	Window time.Duration
+
+}
+
+// Creates discovery options for the local network, with the default port, initial key & listen window
+func NewDiscovery() ( *Discovery ) {
+	return &Discovery{
+		BroadcastAddress: net.IPv4bcast,
+		Port: DefaultPort,
+		InitialKey: DefaultInitialKey,
+		Window: 3 * time.Second,
+	}
+}
+
+// Broadcasts for smart plugs on the local network, listening for the given duration
+func Discover( ctx context.Context, window time.Duration ) ( []DiscoveredDevice, error ) {
+
+	// Use the default options with the given listen window
+	discovery := NewDiscovery()
+	discovery.Window = window
+
+	// Run the discovery
+	return discovery.Run( ctx )
+
+}
+
+// Broadcasts the system information query & collects every response until the listen window ends.
+// If the context finishes first, the devices found so far are returned with the error, which is ErrTimeout if its deadline passed.
+func ( discovery *Discovery ) Run( ctx context.Context ) ( []DiscoveredDevice, error ) {
+
+	// Create the JSON payload containing the query
+	jsonPayload, encodeError := json.Marshal( NewRequest().Add( "system", "get_sysinfo", nil ) )
+	if ( encodeError != nil ) {
+		return nil, encodeError
+	}
+
+	// Open a UDP socket on any available port
+	connection, listenError := net.ListenPacket( "udp4", ":0" )
+	if ( listenError != nil ) {
+		return nil, listenError
+	}
+	defer connection.Close()
+
+	// Stop listening once the window ends, or earlier if the context finishes first
+	deadline := time.Now().Add( discovery.Window )
+	contextDeadline, hasDeadline := ctx.Deadline()
+	if ( hasDeadline && contextDeadline.Before( deadline ) ) {
+		deadline = contextDeadline
+	}
+	deadlineError := connection.SetDeadline( deadline )
+	if ( deadlineError != nil ) {
+		return nil, deadlineError
+	}
+
+	// Unblock any pending read if the context is cancelled
+	stopWatching := context.AfterFunc( ctx, func() {
+		connection.SetDeadline( time.Now() )
+	} )
+	defer stopWatching()
+
+	// Broadcast the encrypted query, which is not length-prefixed over UDP
+	broadcastAddress := &net.UDPAddr{ IP: discovery.BroadcastAddress, Port: discovery.Port }
+	_, writeError := connection.WriteTo( EncryptData( jsonPayload, discovery.InitialKey ), broadcastAddress )
+	if ( writeError != nil ) {
+		return nil, writeError
+	}
+
+	// Holds every device that responded, in the order they responded
+	devices := []DiscoveredDevice{}
+	seenAddresses := map[string]bool{}
+
+	// Read responses until the deadline is reached
+	responseBytes := make( []byte, 65535 )
+	for {
+		responseLength, senderAddress, readError := connection.ReadFrom( responseBytes )
+
+		// Stop once the deadline is reached, failing only if the context finished before the window ended, even if it has not noticed yet
+		var networkError net.Error
+		if ( errors.As( readError, &networkError ) && networkError.Timeout() ) {
+			if ( ctx.Err() != nil || ( hasDeadline && deadline.Equal( contextDeadline ) ) ) {
+				return devices, asTimeoutError( ctx, readError )
+			}
+
+			return devices, nil
+		} else if ( readError != nil ) {
+			return devices, readError
+		}
+
+		// Ignore responses from devices we have already seen
+		senderUDPAddress, isUDPAddress := senderAddress.( *net.UDPAddr )
+		if ( !isUDPAddress || seenAddresses[ senderUDPAddress.IP.String() ] ) {
+			continue
+		}
+
+		// Decrypt the response and parse it as JSON, ignoring anything that is not a valid response
+		var queryResponse QueryResponse
+		decodeError := json.Unmarshal( DecryptData( responseBytes[ : responseLength ], discovery.InitialKey ), &queryResponse )
+		if ( decodeError != nil || queryResponse.System.Info.ErrorCode != 0 ) {
+			continue
+		}
+
+		// Add the device to the list
+		seenAddresses[ senderUDPAddress.IP.String() ] = true
+		devices = append( devices, DiscoveredDevice{
+			Address: senderUDPAddress.IP,
+			MACAddress: queryResponse.System.Info.MACAddress,
+			Alias: queryResponse.System.Info.Alias,
+			Model: queryResponse.System.Info.Model,
+			DeviceIdentifier: queryResponse.System.Info.DeviceIdentifier,
+		} )
+	}
+
+}
@@ -0,0 +1,65 @@
+package kasa_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/viral32111/kasa-smart-plug/source/emulator"
+	"github.com/viral32111/kasa-smart-plug/source/kasa"
+)
+
+// Starts a fake smart plug & returns discovery options that send the query straight to it rather than broadcasting
+func newEmulatorDiscovery( t *testing.T ) ( *emulator.Plug, *kasa.Discovery ) {
+	plug := emulator.New()
+	listenError := plug.Listen( "127.0.0.1:0" )
+	if ( listenError != nil ) {
+		t.Fatal( listenError )
+	}
+	t.Cleanup( func() { plug.Close() } )
+
+	discovery := kasa.NewDiscovery()
+	discovery.BroadcastAddress = net.IPv4( 127, 0, 0, 1 )
+	discovery.Port = plug.Port()
+
+	return plug, discovery
+}
+
+// The smart plug must be found once the listen window ends
+func TestDiscoveryFindsPlug( t *testing.T ) {
+	plug, discovery := newEmulatorDiscovery( t )
+	discovery.Window = 200 * time.Millisecond
+
+	devices, discoverError := discovery.Run( context.Background() )
+	if ( discoverError != nil ) {
+		t.Fatal( discoverError )
+	}
+
+	state := plug.State()
+	if ( len( devices ) != 1 || !devices[ 0 ].Address.Equal( plug.Address() ) || devices[ 0 ].Alias != state.Alias || devices[ 0 ].Model != state.Model || devices[ 0 ].DeviceIdentifier != state.DeviceIdentifier ) {
+		t.Errorf( "expected the emulated smart plug, got %+v", devices )
+	}
+}
+
+// A context deadline before the window ends must end the scan early, still returning what was found
+func TestDiscoveryEndsAtContextDeadline( t *testing.T ) {
+	_, discovery := newEmulatorDiscovery( t )
+	discovery.Window = 10 * time.Second
+
+	ctx, cancel := context.WithTimeout( context.Background(), 200 * time.Millisecond )
+	defer cancel()
+	startedAt := time.Now()
+	devices, discoverError := discovery.Run( ctx )
+
+	if ( time.Since( startedAt ) > 5 * time.Second ) {
+		t.Errorf( "expected the deadline to end the scan, took %s", time.Since( startedAt ) )
+	}
+	if ( !errors.Is( discoverError, kasa.ErrTimeout ) ) {
+		t.Errorf( "expected a timeout, got %v", discoverError )
+	}
+	if ( len( devices ) != 1 ) {
+		t.Errorf( "expected the emulated smart plug to be found first, got %+v", devices )
+	}
+}
@@ -0,0 +1,81 @@
+package kasa
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Returned when a query is sent before connecting to the smart plug
+var ErrNotConnected = errors.New( "not connected to smart plug" )
+
+// Returned when the smart plug does not answer before the timeout of the client or the deadline of the context
+var ErrTimeout = errors.New( "timed out waiting for smart plug" )
+
+// Returned when a frame is longer than the maximum frame size
+var ErrFrameTooLarge = errors.New( "frame is too large" )
+
+// Returned when the connection drops or times out after a query was sent but before the smart plug replied, so it may have been applied
+var ErrNoReply = errors.New( "smart plug did not reply" )
+
+// Returned when a response is not valid JSON or does not answer what was asked
+var ErrInvalidResponse = errors.New( "invalid response from smart plug" )
+
+// Error codes set by the smart plug for modules & methods it does not have
+const (
+	ErrorCodeModuleNotSupported = -1
+	ErrorCodeMethodNotSupported = -2
+)
+
+// Matches a device error for a module the smart plug does not have, using errors.Is
+var ErrModuleNotSupported = errors.New( "module not supported" )
+
+// Matches a device error for a method the smart plug does not have, using errors.Is
+var ErrMethodNotSupported = errors.New( "method not supported" )
+
+// Structure for holding an error set by the smart plug in the result of a method
+type DeviceError struct {
+
+	// The module & method that failed
+	Module string
+	Method string
+
+	// The error code & message set by the smart plug
+	Code int
+	Message string
+
+}
+
+// Describes the error, including the message if the smart plug set one
+func ( deviceError *DeviceError ) Error() ( string ) {
+	if ( deviceError.Message != "" ) {
+		return fmt.Sprintf( "smart plug failed method '%s' in module '%s': %s (%d)", deviceError.Method, deviceError.Module, deviceError.Message, deviceError.Code )
+	}
+
+	return fmt.Sprintf( "smart plug failed method '%s' in module '%s' (%d)", deviceError.Method, deviceError.Module, deviceError.Code )
+}
+
+// Matches the sentinel errors for unsupported modules & methods
+func ( deviceError *DeviceError ) Is( target error ) ( bool ) {
+	switch ( target ) {
+		case ErrModuleNotSupported:
+			return ( deviceError.Code == ErrorCodeModuleNotSupported )
+		case ErrMethodNotSupported:
+			return ( deviceError.Code == ErrorCodeMethodNotSupported )
+	}
+
+	return false
+}
+
+// Creates a device error if the error code is set, otherwise returns nil
+func newDeviceError( moduleName string, methodName string, errorCode int, errorMessage string ) ( error ) {
+	if ( errorCode == 0 ) {
+		return nil
+	}
+
+	return &DeviceError{
+		Module: moduleName,
+		Method: methodName,
+		Code: errorCode,
+		Message: errorMessage,
+	}
+}
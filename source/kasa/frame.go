@@ -0,0 +1,170 @@
+package kasa
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net"
+)
+
+// The default maximum payload length of a frame, which is far larger than anything a smart plug sends
+const DefaultMaxFrameSize = 64 * 1024
+
+// Structure for writing length-prefixed encrypted frames, as sent over TCP
+type FrameWriter struct {
+	writer io.Writer
+	initialKey byte
+	buffer []byte
+}
+
+// Creates a frame writer for the given writer, encrypting each frame from the initial key
+func NewFrameWriter( writer io.Writer, initialKey int ) ( *FrameWriter ) {
+	return &FrameWriter{ writer: writer, initialKey: byte( initialKey ) }
+}
+
+// Encrypts the payload & writes it as a single frame, reusing an internal buffer between frames
+func ( frameWriter *FrameWriter ) WriteFrame( payload []byte ) ( error ) {
+
+	// Grow the buffer if this frame is larger than any before
+	frameLength := 4 + len( payload )
+	if ( cap( frameWriter.buffer ) < frameLength ) {
+		frameWriter.buffer = make( []byte, frameLength )
+	}
+	frame := frameWriter.buffer[ : frameLength ]
+
+	// Write the length of the payload (32-bit integer), then the encrypted payload, as every frame starts from the initial key
+	binary.BigEndian.PutUint32( frame[ : 4 ], uint32( len( payload ) ) )
+	EncryptBytes( frame[ 4 : ], payload, frameWriter.initialKey )
+
+	// Send the whole frame at once
+	_, writeError := frameWriter.writer.Write( frame )
+	return writeError
+
+}
+
+// Structure for reading length-prefixed encrypted frames, as received over TCP
+type FrameReader struct {
+
+	// The maximum payload length to accept, so a misbehaving peer cannot make us allocate gigabytes, or zero for no limit
+	MaxFrameSize int
+
+	reader io.Reader
+	initialKey byte
+	header [ 4 ]byte
+
+}
+
+// Creates a frame reader for the given reader, decrypting each frame from the initial key & accepting up to the default maximum frame size
+func NewFrameReader( reader io.Reader, initialKey int ) ( *FrameReader ) {
+	return &FrameReader{ MaxFrameSize: DefaultMaxFrameSize, reader: reader, initialKey: byte( initialKey ) }
+}
+
+// Reads a single frame & returns the decrypted payload, reusing the given buffer if it is large enough (nil to always allocate)
+func ( frameReader *FrameReader ) ReadFrame( buffer []byte ) ( []byte, error ) {
+
+	// Read the encrypted payload length (32-bit integer), without consuming anything beyond this frame
+	_, headerReadError := io.ReadFull( frameReader.reader, frameReader.header[ : ] )
+	if ( headerReadError != nil ) {
+		return nil, headerReadError
+	}
+	encodedLength := uint64( binary.BigEndian.Uint32( frameReader.header[ : ] ) )
+
+	// Fail before allocating anything if the payload is too large, comparing before converting as lengths over 2 GiB are negative as an int on 32-bit systems
+	if ( frameReader.MaxFrameSize > 0 && encodedLength > uint64( frameReader.MaxFrameSize ) ) {
+		return nil, fmt.Errorf( "%w: %d bytes is over the maximum of %d bytes", ErrFrameTooLarge, encodedLength, frameReader.MaxFrameSize )
+	} else if ( encodedLength > uint64( math.MaxInt ) ) {
+		return nil, fmt.Errorf( "%w: %d bytes is over the maximum of %d bytes", ErrFrameTooLarge, encodedLength, math.MaxInt )
+	}
+	payloadLength := int( encodedLength )
+
+	// Grow the buffer if it is too small for the payload
+	if ( cap( buffer ) < payloadLength ) {
+		buffer = make( []byte, payloadLength )
+	}
+	payload := buffer[ : payloadLength ]
+
+	// Read the encrypted payload, the end of the stream part-way through is unexpected
+	_, payloadReadError := io.ReadFull( frameReader.reader, payload )
+	if ( payloadReadError == io.EOF ) {
+		return nil, io.ErrUnexpectedEOF
+	} else if ( payloadReadError != nil ) {
+		return nil, payloadReadError
+	}
+
+	// Decrypt the payload in place
+	DecryptBytes( payload, payload, frameReader.initialKey )
+
+	// Return the decrypted payload
+	return payload, nil
+
+}
+
+// Structure for wrapping a connection so each write is sent as a frame & reads return the decrypted payloads
+type Conn struct {
+	net.Conn
+
+	// The frame reader & writer for the underlying connection
+	frameReader *FrameReader
+	frameWriter *FrameWriter
+
+	// The rest of the payload from the last frame, for reads smaller than a frame
+	pending []byte
+	buffer []byte
+}
+
+// Wraps a connection to encrypt & frame everything, starting each frame from the initial key
+func NewConn( connection net.Conn, initialKey int ) ( *Conn ) {
+	return &Conn{
+		Conn: connection,
+		frameReader: NewFrameReader( connection, initialKey ),
+		frameWriter: NewFrameWriter( connection, initialKey ),
+	}
+}
+
+// Sets the maximum payload length to accept when reading frames, or zero for no limit
+func ( conn *Conn ) SetMaxFrameSize( maxFrameSize int ) {
+	conn.frameReader.MaxFrameSize = maxFrameSize
+}
+
+// Reads a single frame & returns the decrypted payload, reusing the given buffer if it is large enough (nil to always allocate)
+func ( conn *Conn ) ReadFrame( buffer []byte ) ( []byte, error ) {
+	return conn.frameReader.ReadFrame( buffer )
+}
+
+// Encrypts the payload & writes it as a single frame
+func ( conn *Conn ) WriteFrame( payload []byte ) ( error ) {
+	return conn.frameWriter.WriteFrame( payload )
+}
+
+// Reads the decrypted payloads as a continuous stream, reading the next frame once the last one has been consumed
+func ( conn *Conn ) Read( data []byte ) ( int, error ) {
+
+	// Read the next frame if everything from the last one has been consumed, skipping empty frames
+	for ( len( conn.pending ) == 0 ) {
+		payload, readError := conn.frameReader.ReadFrame( conn.buffer )
+		if ( readError != nil ) {
+			return 0, readError
+		}
+
+		conn.buffer = payload[ : cap( payload ) ]
+		conn.pending = payload
+	}
+
+	// Copy as much of the payload as fits
+	copiedCount := copy( data, conn.pending )
+	conn.pending = conn.pending[ copiedCount : ]
+
+	return copiedCount, nil
+
+}
+
+// Encrypts the data & writes it as a single frame
+func ( conn *Conn ) Write( data []byte ) ( int, error ) {
+	writeError := conn.frameWriter.WriteFrame( data )
+	if ( writeError != nil ) {
+		return 0, writeError
+	}
+
+	return len( data ), nil
+}
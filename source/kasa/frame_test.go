@@ -0,0 +1,127 @@
+package kasa
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"testing"
+)
+
+// Lengths at or above 2 GiB must be rejected rather than becoming negative on 32-bit systems
+func TestReadFrameRejectsHugeLength( t *testing.T ) {
+	for _, header := range [][]byte{
+		{ 0xFF, 0xFF, 0xFF, 0xF0 },
+		{ 0x80, 0x00, 0x00, 0x00 },
+	} {
+		frameReader := NewFrameReader( bytes.NewReader( header ), DefaultInitialKey )
+		_, readError := frameReader.ReadFrame( nil )
+		if ( !errors.Is( readError, ErrFrameTooLarge ) ) {
+			t.Errorf( "header %x: expected ErrFrameTooLarge, got %v", header, readError )
+		}
+	}
+}
+
+// A frame written by the writer must read back as the same payload
+func TestFrameRoundTrip( t *testing.T ) {
+	var stream bytes.Buffer
+	payload := []byte( `{"system":{"get_sysinfo":{}}}` )
+
+	writeError := NewFrameWriter( &stream, DefaultInitialKey ).WriteFrame( payload )
+	if ( writeError != nil ) {
+		t.Fatal( writeError )
+	}
+
+	readPayload, readError := NewFrameReader( &stream, DefaultInitialKey ).ReadFrame( nil )
+	if ( readError != nil ) {
+		t.Fatal( readError )
+	}
+	if ( !bytes.Equal( readPayload, payload ) ) {
+		t.Errorf( "expected %q, got %q", payload, readPayload )
+	}
+}
+
+// Reading arbitrary bytes as frames must never panic or return a payload over the maximum frame size
+func FuzzFrameReader( f *testing.F ) {
+	f.Add( []byte{ 0x00, 0x00, 0x00, 0x02, 0xD0, 0xF2 } )
+	f.Add( []byte{ 0xFF, 0xFF, 0xFF, 0xF0, 0x00 } )
+	f.Add( []byte{ 0x80, 0x00, 0x00, 0x00 } )
+	f.Add( []byte{ 0x00, 0x00, 0x00 } )
+
+	f.Fuzz( func( t *testing.T, stream []byte ) {
+		frameReader := NewFrameReader( bytes.NewReader( stream ), DefaultInitialKey )
+		frameReader.MaxFrameSize = 4096
+
+		// Read frames until the stream ends or is rejected, reusing the buffer like the client does
+		var buffer []byte
+		for {
+			payload, readError := frameReader.ReadFrame( buffer )
+			if ( readError != nil ) {
+				return
+			}
+			if ( len( payload ) > frameReader.MaxFrameSize ) {
+				t.Fatalf( "payload of %d bytes is over the maximum", len( payload ) )
+			}
+
+			buffer = payload
+		}
+	} )
+}
+
+// Reading with a buffer smaller than a frame must return the rest of the frame on the next reads, skipping empty frames
+func TestConnReadSmallBuffer( t *testing.T ) {
+	clientSide, serverSide := net.Pipe()
+	defer clientSide.Close()
+	defer serverSide.Close()
+
+	// Write each frame in the background, as the pipe waits for the other side to read
+	frames := [][]byte{ []byte( `{"system":` ), {}, []byte( `{"get_sysinfo":{}}}` ) }
+	go func() {
+		writer := NewConn( serverSide, DefaultInitialKey )
+		for _, frame := range frames {
+			_, writeError := writer.Write( frame )
+			if ( writeError != nil ) {
+				return
+			}
+		}
+		serverSide.Close()
+	}()
+
+	// Read the frames a few bytes at a time
+	reader := NewConn( clientSide, DefaultInitialKey )
+	var received []byte
+	buffer := make( []byte, 3 )
+	for {
+		readCount, readError := reader.Read( buffer )
+		received = append( received, buffer[ : readCount ]... )
+		if ( errors.Is( readError, io.EOF ) ) {
+			break
+		} else if ( readError != nil ) {
+			t.Fatal( readError )
+		}
+	}
+
+	expected := bytes.Join( frames, nil )
+	if ( !bytes.Equal( received, expected ) ) {
+		t.Errorf( "expected %q, got %q", expected, received )
+	}
+}
+
+// Each frame must start again from the initial key, rather than continuing from the last frame
+func TestFramesRestartKey( t *testing.T ) {
+	var stream bytes.Buffer
+	frameWriter := NewFrameWriter( &stream, DefaultInitialKey )
+	payload := []byte( `{"system":{"get_sysinfo":{}}}` )
+	for range 2 {
+		writeError := frameWriter.WriteFrame( payload )
+		if ( writeError != nil ) {
+			t.Fatal( writeError )
+		}
+	}
+
+	// Both frames are encrypted the same, after the 4 byte length
+	frameLength := 4 + len( payload )
+	if ( !bytes.Equal( stream.Bytes()[ 4 : frameLength ], stream.Bytes()[ frameLength + 4 : ] ) ) {
+		t.Error( "expected both frames to be encrypted the same" )
+	}
+}
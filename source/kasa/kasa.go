@@ -0,0 +1,16 @@
+// Package kasa is a client for the local network API of TP-Link's Kasa smart plugs.
+package kasa
+
+/*
+https://www.softscheck.com/en/reverse-engineering-tp-link-hs110/
+	https://github.com/softScheck/tplink-smartplug/blob/master/tplink-smarthome-commands.txt
+
+https://www.bencode.net/papers/2021-simmonds-radiosec-tplink-kp115-teardown.pdf
+https://github.com/SimonWilkinson/python-kasa
+*/
+
+// Defaults for connecting to a smart plug
+const (
+	DefaultPort = 9999
+	DefaultInitialKey = 171
+)
@@ -0,0 +1,88 @@
+package kasa
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+// The longest alias the smart plug accepts, in characters
+const MaxAliasLength = 31
+
+// Sets the name of the smart plug, as shown in the Kasa app
+func ( client *Client ) SetAlias( ctx context.Context, alias string ) ( error ) {
+
+	// Require an alias that the smart plug will accept
+	if ( alias == "" ) {
+		return errors.New( "alias cannot be empty" )
+	}
+	if ( utf8.RuneCountInString( alias ) > MaxAliasLength ) {
+		return fmt.Errorf( "alias is %d characters, must be at most %d", utf8.RuneCountInString( alias ), MaxAliasLength )
+	}
+
+	// Send the alias command
+	_, queryError := client.SendQuery( ctx, "system", "set_dev_alias", map[string]string {
+		"alias": alias,
+	} )
+	if ( queryError != nil ) {
+		return queryError
+	}
+
+	// Return no error
+	return nil
+
+}
+
+// Sets the position of the smart plug, which it uses to work out sunrise & sunset
+func ( client *Client ) SetLocation( ctx context.Context, latitude float64, longitude float64 ) ( error ) {
+
+	// Require coordinates on the Earth
+	if ( math.IsNaN( latitude ) || latitude < -90 || latitude > 90 ) {
+		return fmt.Errorf( "invalid latitude %f, must be between -90 and 90", latitude )
+	}
+	if ( math.IsNaN( longitude ) || longitude < -180 || longitude > 180 ) {
+		return fmt.Errorf( "invalid longitude %f, must be between -180 and 180", longitude )
+	}
+
+	// Send the location command, with both the decimal & the ten-thousandths forms as firmware versions differ in which they read
+	_, queryError := client.SendQuery( ctx, "system", "set_dev_location", map[string]any {
+		"latitude": latitude,
+		"longitude": longitude,
+		"latitude_i": int( math.Round( latitude * 10000.0 ) ),
+		"longitude_i": int( math.Round( longitude * 10000.0 ) ),
+	} )
+	if ( queryError != nil ) {
+		return queryError
+	}
+
+	// Return no error
+	return nil
+
+}
+
+// Sets the icon of the smart plug by its hash, as shown in the Kasa app, optionally with the image data for a custom icon
+func ( client *Client ) SetIcon( ctx context.Context, iconHash string, image []byte ) ( error ) {
+
+	// Require a hash of whole hexadecimal bytes, or none to clear the icon
+	_, decodeError := hex.DecodeString( iconHash )
+	if ( decodeError != nil ) {
+		return fmt.Errorf( "invalid icon hash '%s', must be an even number of hexadecimal digits", iconHash )
+	}
+
+	// Send the icon command
+	_, queryError := client.SendQuery( ctx, "system", "set_dev_icon", map[string]string {
+		"icon": base64.StdEncoding.EncodeToString( image ),
+		"hash": iconHash,
+	} )
+	if ( queryError != nil ) {
+		return queryError
+	}
+
+	// Return no error
+	return nil
+
+}
@@ -0,0 +1,524 @@
+package kasa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Structure for holding a snapshot of the data about a smart plug
+type SmartPlug struct {
+
+	// Runtime & state
+	Alias string
+	Icon string
+	PowerState bool
+	LightState bool
+	Uptime int
+
+	// Device information
+	DeviceName string
+	DeviceModel string
+	DeviceIdentifier string
+	DeviceFeatures []string
+	HardwareVersion string
+	HardwareIdentifier string
+	OEMIdentifier string
+
+	// Status & firmware
+	Status string
+	FirmwareUpdating bool
+	FirmwareVersion string
+
+	// Network
+	SignalStrength int
+	MACAddress string
+
+	// Position
+	Latitude float64
+	Longitude float64
+
+	// TO-DO: Work out what these three are...
+	Source string
+	Type string
+	NTCState int
+
+	// Current action
+	Action Action
+
+	// What the smart plug can do, from its features, model & type
+	Capabilities Capabilities
+
+	// Time
+	Time time.Time
+
+	// Energy usage
+	Energy EnergyUsage
+
+	// The outlets, only on power strips
+	Outlets []Outlet
+}
+
+// Structure for holding the current action of a smart plug
+type Action struct {
+	Name string
+	Type int
+	Identifier string
+	ScheduledSeconds int
+	Action int
+
+	// The time left on the countdown in progress, or zero if there is none
+	CountdownRemaining time.Duration
+}
+
+// Structure for holding the real-time energy usage of a smart plug
+type EnergyUsage struct {
+	Amperage float64 // amps
+	Voltage float64 // volts
+	Wattage float64 // watts
+	Total int // watthours
+}
+
+// Fetches the system information, without the time or energy usage
+func ( client *Client ) GetSystemInformation( ctx context.Context ) ( SmartPlug, error ) {
+
+	// Fetch the system information
+	queryResponse, sendError := client.SendQuery( ctx, "system", "get_sysinfo", nil )
+	if ( sendError != nil ) {
+		return SmartPlug{}, sendError
+	}
+
+	// Convert the system information
+	return client.smartPlugFromResponse( queryResponse )
+
+}
+
+// Populates a snapshot from the response to a system information query, without the time or energy usage
+func ( client *Client ) smartPlugFromResponse( queryResponse QueryResponse ) ( SmartPlug, error ) {
+
+	// Shorthand for the system information, using the older field for the type if needed
+	info := queryResponse.System.Info
+	if ( info.Type == "" ) {
+		info.Type = info.LegacyType
+	}
+
+	// Populate the snapshot from the response
+	smartPlug := SmartPlug{
+
+		// Runtime & state properties
+		Alias: info.Alias,
+		Icon: info.IconHash,
+		PowerState: ( info.RelayState != 0 ),
+		LightState: ( info.LEDOff == 0 ),
+		Uptime: info.UptimeSeconds,
+
+		// Device information properties
+		DeviceName: info.DeviceName,
+		DeviceModel: info.Model,
+		DeviceIdentifier: info.DeviceIdentifier,
+		DeviceFeatures: strings.Split( info.Features, ":" ),
+		HardwareVersion: info.HardwareVersion,
+		HardwareIdentifier: info.HardwareIdentifier,
+		OEMIdentifier: info.OEMIdentifier,
+
+		// Status & firmware properties
+		Status: info.Status,
+		FirmwareUpdating: ( info.Updating != 0 ),
+		FirmwareVersion: info.SoftwareVersion,
+
+		// Network properties
+		SignalStrength: info.SignalStrength,
+		MACAddress: info.MACAddress,
+
+		// Position properties
+		Latitude: float64( info.Latitude ) / 10000.0,
+		Longitude: float64( info.Longitude ) / 10000.0,
+
+		// ???? properties
+		Source: info.Source,
+		Type: info.Type,
+		NTCState: info.NTCState,
+
+		// Current action properties
+		Action: Action{
+			Name: info.ActiveMode,
+			Type: info.NextAction.Type,
+			Identifier: info.NextAction.Identifier,
+			ScheduledSeconds: info.NextAction.ScheduledSeconds,
+			Action: info.NextAction.Action,
+		},
+
+		// Capability properties
+		Capabilities: DetectCapabilities( info.Features, info.Model, info.Type, len( info.Children ) ),
+
+	}
+
+	// Remember what the smart plug can do, for checking before using modules it may not have
+	client.rememberCapabilities( smartPlug.Capabilities, info.Model )
+
+	// Populate the outlets, on power strips
+	for _, child := range info.Children {
+		smartPlug.Outlets = append( smartPlug.Outlets, Outlet{
+			Identifier: outletIdentifier( info.DeviceIdentifier, child.Identifier ),
+			Alias: child.Alias,
+			PowerState: ( child.State != 0 ),
+			Uptime: child.UptimeSeconds,
+			Action: Action{
+				Name: info.ActiveMode,
+				Type: child.NextAction.Type,
+				Identifier: child.NextAction.Identifier,
+				ScheduledSeconds: child.NextAction.ScheduledSeconds,
+				Action: child.NextAction.Action,
+			},
+		} )
+	}
+
+	// Use the runtime & state of the outlet, if this client is for one
+	if ( client.childIdentifier != "" ) {
+		isOutletFound := false
+		for _, outlet := range smartPlug.Outlets {
+			if ( outlet.Identifier == outletIdentifier( info.DeviceIdentifier, client.childIdentifier ) ) {
+				smartPlug.Alias = outlet.Alias
+				smartPlug.PowerState = outlet.PowerState
+				smartPlug.Uptime = outlet.Uptime
+				smartPlug.Action = outlet.Action
+				isOutletFound = true
+			}
+		}
+
+		if ( !isOutletFound ) {
+			return SmartPlug{}, fmt.Errorf( "no outlet with identifier '%s'", client.childIdentifier )
+		}
+	}
+
+	// Return the populated snapshot
+	return smartPlug, nil
+
+}
+
+// Fetches all the properties with the latest data
+func ( client *Client ) GetProperties( ctx context.Context ) ( SmartPlug, error ) {
+
+	// Fetch the system information
+	smartPlug, infoError := client.GetSystemInformation( ctx )
+	if ( infoError != nil ) {
+		return SmartPlug{}, infoError
+	}
+
+	// Fetch the time, if the smart plug has a clock
+	if ( smartPlug.Capabilities.Has( CapabilityClock ) ) {
+		currentTime, timeError := client.GetTime( ctx )
+		if ( timeError != nil ) {
+			return SmartPlug{}, timeError
+		}
+		smartPlug.Time = currentTime
+	}
+
+	// Fetch the energy usage, including each outlet on power strips, if the smart plug has an energy meter
+	if ( smartPlug.Capabilities.Has( CapabilityEnergyMeter ) ) {
+
+		// Power strips may only measure each outlet, like when fetching the energy usage of the outlets
+		energyUsage, energyUsageError := client.GetEnergyUsage( ctx )
+		isOnlyOutletsMeasured := ( client.childIdentifier == "" && len( smartPlug.Outlets ) > 0 && errors.Is( energyUsageError, ErrModuleNotSupported ) )
+		if ( energyUsageError != nil && !isOnlyOutletsMeasured ) {
+			return SmartPlug{}, energyUsageError
+		}
+		smartPlug.Energy = energyUsage
+
+		outletEnergyUsageError := client.getOutletEnergyUsage( ctx, smartPlug.Outlets )
+		if ( outletEnergyUsageError != nil ) {
+			return SmartPlug{}, outletEnergyUsageError
+		}
+
+		// Add up the outlets instead, if only they are measured
+		if ( isOnlyOutletsMeasured ) {
+			smartPlug.Energy = sumEnergyUsage( smartPlug.Outlets )
+		}
+
+	}
+
+	// Fetch the countdown in progress, if the smart plug has timers, which older smart plugs may not support anyway
+	if ( smartPlug.Capabilities.Has( CapabilityTimer ) ) {
+		countdown, isCountingDown, countdownError := client.GetActiveCountdown( ctx )
+		if ( countdownError != nil && !errors.Is( countdownError, ErrModuleNotSupported ) ) {
+			return SmartPlug{}, countdownError
+		}
+		if ( isCountingDown ) {
+			smartPlug.Action.CountdownRemaining = countdown.Remaining
+		}
+	}
+
+	// Return the populated snapshot
+	return smartPlug, nil
+
+}
+
+// Get the current time, in the smart plug's timezone
+func ( client *Client ) GetTime( ctx context.Context ) ( time.Time, error ) {
+
+	// Fetch the current time & timezone together
+	response, sendError := client.Send( ctx, NewRequest().Add( "time", "get_time", nil ).Add( "time", "get_timezone", nil ) )
+	if ( sendError != nil ) {
+		return time.Time{}, sendError
+	}
+
+	// Parse both results into the response structure
+	var queryResponse QueryResponse
+	timeDecodeError := response.Decode( "time", "get_time", &queryResponse.Time.Now )
+	if ( timeDecodeError != nil ) {
+		return time.Time{}, timeDecodeError
+	}
+	zoneDecodeError := response.Decode( "time", "get_timezone", &queryResponse.Time.Zone )
+	if ( zoneDecodeError != nil ) {
+		return time.Time{}, zoneDecodeError
+	}
+
+	// Convert the timezone index to a location
+	location, locationError := TimezoneLocation( queryResponse.Time.Zone.Index )
+	if ( locationError != nil ) {
+		return time.Time{}, locationError
+	}
+
+	// The smart plug reports its local wall clock, so interpret it in that location
+	now := queryResponse.Time.Now
+	return time.Date( now.Year, time.Month( now.Month ), now.Day, now.Hour, now.Minute, now.Second, 0, location ), nil
+
+}
+
+// Get the real-time energy usage
+func ( client *Client ) GetEnergyUsage( ctx context.Context ) ( EnergyUsage, error ) {
+
+	// Fail if the smart plug has no energy meter
+	requireError := client.require( ctx, CapabilityEnergyMeter )
+	if ( requireError != nil ) {
+		return EnergyUsage{}, requireError
+	}
+
+	// Send the energy usage command
+	queryResponse, queryError := client.SendQuery( ctx, "emeter", "get_realtime", nil )
+	if ( queryError != nil ) {
+		return EnergyUsage{}, queryError
+	}
+
+	// Convert the units & return the energy usage, preferring the base units from older firmware when they are present
+	now := queryResponse.EnergyMeter.Now
+	return EnergyUsage{
+		Amperage: legacyOrScaled( now.LegacyAmperage, now.Amperage, 1000.0 ),
+		Voltage: legacyOrScaled( now.LegacyVoltage, now.Voltage, 1000.0 ),
+		Wattage: legacyOrScaled( now.LegacyWattage, now.Wattage, 1000.0 ),
+		Total: legacyWatthours( now.LegacyTotal, now.Total ),
+	}, nil
+
+}
+
+// Sets the power relay state
+func ( client *Client ) SetPowerState( ctx context.Context, powerState bool ) ( error ) {
+
+	// Convert the power state to the value expected by the smart plug
+	relayState := 0
+	if ( powerState ) {
+		relayState = 1
+	}
+
+	// Send the power command
+	_, queryError := client.SendQuery( ctx, "system", "set_relay_state", map[string]int { "state": relayState } )
+	if ( queryError != nil ) {
+		return queryError
+	}
+
+	// Return no error
+	return nil
+
+}
+
+// Switches on power
+func ( client *Client ) PowerOn( ctx context.Context ) ( error ) {
+
+	// Fetch the current state
+	smartPlug, infoError := client.GetSystemInformation( ctx )
+	if ( infoError != nil ) {
+		return infoError
+	}
+
+	// Fail if the plug is already switched on
+	if ( smartPlug.PowerState ) {
+		return errors.New( "smart plug is already powered on" )
+	}
+
+	// Send the power on command
+	return client.SetPowerState( ctx, true )
+
+}
+
+// Switches off power
+func ( client *Client ) PowerOff( ctx context.Context ) ( error ) {
+
+	// Fetch the current state
+	smartPlug, infoError := client.GetSystemInformation( ctx )
+	if ( infoError != nil ) {
+		return infoError
+	}
+
+	// Fail if the plug is already switched off
+	if ( !smartPlug.PowerState ) {
+		return errors.New( "smart plug is already powered off" )
+	}
+
+	// Send the power off command
+	return client.SetPowerState( ctx, false )
+
+}
+
+// Toggle power, returning the new power state
+func ( client *Client ) PowerToggle( ctx context.Context ) ( bool, error ) {
+
+	// Fetch the current state
+	smartPlug, infoError := client.GetSystemInformation( ctx )
+	if ( infoError != nil ) {
+		return false, infoError
+	}
+
+	// Send the opposite of the current power state
+	setError := client.SetPowerState( ctx, !smartPlug.PowerState )
+	if ( setError != nil ) {
+		return smartPlug.PowerState, setError
+	}
+
+	// Return the new power state
+	return !smartPlug.PowerState, nil
+
+}
+
+// Sets the power indicator light state
+func ( client *Client ) SetLightState( ctx context.Context, lightState bool ) ( error ) {
+
+	// Convert the light state to the value expected by the smart plug
+	lightOff := 1
+	if ( lightState ) {
+		lightOff = 0
+	}
+
+	// Send the light command
+	_, queryError := client.SendQuery( ctx, "system", "set_led_off", map[string]int { "off": lightOff } )
+	if ( queryError != nil ) {
+		return queryError
+	}
+
+	// Return no error
+	return nil
+
+}
+
+// Switch on the power indicator light
+func ( client *Client ) LightOn( ctx context.Context ) ( error ) {
+
+	// Fetch the current state
+	smartPlug, infoError := client.GetSystemInformation( ctx )
+	if ( infoError != nil ) {
+		return infoError
+	}
+
+	// Fail if the light is already on
+	if ( smartPlug.LightState ) {
+		return errors.New( "smart plug light is already on" )
+	}
+
+	// Send the light on command
+	return client.SetLightState( ctx, true )
+
+}
+
+// Switch off the power indicator light
+func ( client *Client ) LightOff( ctx context.Context ) ( error ) {
+
+	// Fetch the current state
+	smartPlug, infoError := client.GetSystemInformation( ctx )
+	if ( infoError != nil ) {
+		return infoError
+	}
+
+	// Fail if the light is already off
+	if ( !smartPlug.LightState ) {
+		return errors.New( "smart plug light is already off" )
+	}
+
+	// Send the light off command
+	return client.SetLightState( ctx, false )
+
+}
+
+// Toggle the power indicator light, returning the new light state
+func ( client *Client ) LightToggle( ctx context.Context ) ( bool, error ) {
+
+	// Fetch the current state
+	smartPlug, infoError := client.GetSystemInformation( ctx )
+	if ( infoError != nil ) {
+		return false, infoError
+	}
+
+	// Send the opposite of the current light state
+	setError := client.SetLightState( ctx, !smartPlug.LightState )
+	if ( setError != nil ) {
+		return smartPlug.LightState, setError
+	}
+
+	// Return the new light state
+	return !smartPlug.LightState, nil
+
+}
+
+// Get the power-on time
+func ( client *Client ) GetPowerTime( ctx context.Context ) ( time.Time, error ) {
+
+	// Fetch the current state
+	smartPlug, infoError := client.GetSystemInformation( ctx )
+	if ( infoError != nil ) {
+		return time.Time{}, infoError
+	}
+
+	// Fail if the plug is not powered on
+	if ( !smartPlug.PowerState ) {
+		return time.Time{}, errors.New( "smart plug is not powered on" )
+	}
+
+	// Fetch the current time
+	currentTime, timeError := client.GetTime( ctx )
+	if ( timeError != nil ) {
+		return time.Time{}, timeError
+	}
+
+	// Return the power-on time
+	return currentTime.Add( time.Duration( -smartPlug.Uptime ) * time.Second ), nil
+
+}
+
+// Restart after the given number of seconds
+func ( client *Client ) Reboot( ctx context.Context, delay int ) ( error ) {
+
+	// Send the restart command
+	_, queryError := client.SendQuery( ctx, "system", "reboot", map[string]int { "delay": int( math.Max( 1.0, float64( delay ) ) ) } )
+	if ( queryError != nil ) {
+		return queryError
+	}
+
+	// Return no error
+	return nil
+
+}
+
+// Factory resets the smart plug after the given number of seconds, erasing all settings, after which it leaves the wireless network & creates its own
+func ( client *Client ) Reset( ctx context.Context, delay int ) ( error ) {
+
+	// Send the factory reset command
+	_, queryError := client.SendQuery( ctx, "system", "reset", map[string]int { "delay": int( math.Max( 1.0, float64( delay ) ) ) } )
+	if ( queryError != nil ) {
+		return queryError
+	}
+
+	// Return no error
+	return nil
+
+}
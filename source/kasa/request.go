@@ -0,0 +1,247 @@
+package kasa
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Structure for building a query of one or more methods across one or more modules, sent as a single frame
+type Request struct {
+
+	// The arguments for each method, keyed by module name then method name
+	modules map[string]map[string]any
+
+	// The module & method names in the order they were added
+	order []requestMethod
+
+	// The outlets of a power strip to address, or none for the smart plug itself
+	childIdentifiers []string
+
+}
+
+// Structure for holding the name of a method & the module it belongs to
+type requestMethod struct {
+	Module string
+	Method string
+}
+
+// Creates an empty request
+func NewRequest() ( *Request ) {
+	return &Request{
+		modules: map[string]map[string]any{},
+	}
+}
+
+// Adds a method to the request, with any JSON-serialisable arguments (nil for none)
+func ( request *Request ) Add( moduleName string, methodName string, arguments any ) ( *Request ) {
+
+	// Methods without arguments still require an empty object
+	if ( arguments == nil ) {
+		arguments = struct{}{}
+	}
+
+	// Create the module if it does not exist yet
+	if ( request.modules[ moduleName ] == nil ) {
+		request.modules[ moduleName ] = map[string]any{}
+	}
+
+	// Remember the order, unless the method is being replaced
+	_, exists := request.modules[ moduleName ][ methodName ]
+	if ( !exists ) {
+		request.order = append( request.order, requestMethod{ moduleName, methodName } )
+	}
+
+	// Set the arguments for the method
+	request.modules[ moduleName ][ methodName ] = arguments
+
+	// Return the request for chaining
+	return request
+
+}
+
+// Addresses the request to outlets of a power strip, by their identifiers
+func ( request *Request ) ForChildren( childIdentifiers ...string ) ( *Request ) {
+	request.childIdentifiers = childIdentifiers
+
+	return request
+}
+
+// Checks if every method in the request only reads from the smart plug, so the request is safe to send again
+func ( request *Request ) IsReadOnly() ( bool ) {
+	for _, name := range request.order {
+		if ( !strings.HasPrefix( name.Method, "get_" ) ) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Encodes the request as the JSON payload expected by the smart plug
+func ( request *Request ) MarshalJSON() ( []byte, error ) {
+
+	// Encode the modules
+	modulesJSON, encodeError := json.Marshal( request.modules )
+	if ( encodeError != nil || len( request.childIdentifiers ) == 0 ) {
+		return modulesJSON, encodeError
+	}
+
+	// Encode the outlets to address
+	contextJSON, encodeError := json.Marshal( map[string][]string{ "child_ids": request.childIdentifiers } )
+	if ( encodeError != nil ) {
+		return nil, encodeError
+	}
+
+	// Put the outlets before the modules, as power strips expect
+	payload := append( []byte( `{"context":` ), contextJSON... )
+	if ( len( request.modules ) > 0 ) {
+		payload = append( payload, ',' )
+	}
+	payload = append( payload, modulesJSON[ 1 : ]... )
+
+	// Return the whole payload
+	return payload, nil
+
+}
+
+// Structure for holding the result of a single method within a response
+type Result struct {
+
+	// The module & method this is the result of
+	Module string
+	Method string
+
+	// The error code & message, if any, set by the smart plug
+	ErrorCode int
+	ErrorMessage string
+
+	// The raw JSON of the result, including the error code & message
+	Data json.RawMessage
+
+}
+
+// Structure for holding the results of every method in a request
+type Response struct {
+	results []Result
+}
+
+// Returns the results in the order the methods were added to the request
+func ( response Response ) Results() ( []Result ) {
+	return response.results
+}
+
+// Returns the result of a method, if it was in the request
+func ( response Response ) Result( moduleName string, methodName string ) ( Result, bool ) {
+	for _, result := range response.results {
+		if ( result.Module == moduleName && result.Method == methodName ) {
+			return result, true
+		}
+	}
+
+	return Result{}, false
+}
+
+// Parses the result of a method into the given value, failing if the smart plug set an error
+func ( response Response ) Decode( moduleName string, methodName string, value any ) ( error ) {
+
+	// Fail if the method was not in the request
+	result, exists := response.Result( moduleName, methodName )
+	if ( !exists ) {
+		return fmt.Errorf( "no result for method '%s' in module '%s'", methodName, moduleName )
+	}
+
+	// Parse the result
+	return result.Decode( value )
+
+}
+
+// Returns a device error if the smart plug set an error for this method
+func ( result Result ) Err() ( error ) {
+	return newDeviceError( result.Module, result.Method, result.ErrorCode, result.ErrorMessage )
+}
+
+// Parses the result into the given value, failing if the smart plug set an error
+func ( result Result ) Decode( value any ) ( error ) {
+
+	// Fail if there is an error set
+	resultError := result.Err()
+	if ( resultError != nil ) {
+		return resultError
+	}
+
+	// Nothing to parse into if the caller only cares about success
+	if ( value == nil ) {
+		return nil
+	}
+
+	// Parse the result
+	decodeError := json.Unmarshal( result.Data, value )
+	if ( decodeError != nil ) {
+		return fmt.Errorf( "%w: result of method '%s' in module '%s': %w", ErrInvalidResponse, result.Method, result.Module, decodeError )
+	}
+
+	// Return no error
+	return nil
+
+}
+
+// Parses the JSON payload of a response, producing a result for every method in the request
+func parseResponse( request *Request, responsePayload []byte ) ( Response, error ) {
+
+	// Parse the payload into its modules
+	var modules map[string]json.RawMessage
+	decodeError := json.Unmarshal( responsePayload, &modules )
+	if ( decodeError != nil ) {
+		return Response{}, fmt.Errorf( "%w: %w", ErrInvalidResponse, decodeError )
+	}
+
+	// Create a result for each method in the request, in order
+	results := make( []Result, 0, len( request.order ) )
+	for _, name := range request.order {
+		result := Result{ Module: name.Module, Method: name.Method }
+
+		// Parse the module into its methods, or into an error if the module is not supported
+		var methods map[string]json.RawMessage
+		methodsDecodeError := json.Unmarshal( modules[ name.Module ], &methods )
+		_, isModuleError := methods[ "err_code" ]
+
+		// The module might be missing entirely, or not be an object
+		if ( modules[ name.Module ] == nil ) {
+			return Response{}, fmt.Errorf( "%w: missing module '%s'", ErrInvalidResponse, name.Module )
+		} else if ( methodsDecodeError != nil ) {
+			return Response{}, fmt.Errorf( "%w: module '%s': %w", ErrInvalidResponse, name.Module, methodsDecodeError )
+
+		// An error for the whole module is set in place of the methods
+		} else if ( isModuleError ) {
+			result.Data = modules[ name.Module ]
+
+		// The method might be missing from the module
+		} else if ( methods[ name.Method ] == nil ) {
+			return Response{}, fmt.Errorf( "%w: missing method '%s' in module '%s'", ErrInvalidResponse, name.Method, name.Module )
+
+		// Otherwise use the result of the method
+		} else {
+			result.Data = methods[ name.Method ]
+		}
+
+		// Extract the error code & message
+		var status struct {
+			ErrorCode int `json:"err_code"`
+			ErrorMessage string `json:"err_msg"`
+		}
+		statusDecodeError := json.Unmarshal( result.Data, &status )
+		if ( statusDecodeError != nil ) {
+			return Response{}, fmt.Errorf( "%w: result of method '%s' in module '%s': %w", ErrInvalidResponse, name.Method, name.Module, statusDecodeError )
+		}
+		result.ErrorCode = status.ErrorCode
+		result.ErrorMessage = status.ErrorMessage
+
+		// Add the result to the list
+		results = append( results, result )
+	}
+
+	// Return the results
+	return Response{ results: results }, nil
+
+}
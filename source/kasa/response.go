@@ -0,0 +1,129 @@
+package kasa
+
+// Structure for parsing the JSON query responses
+type QueryResponse struct {
+	System struct {
+		Info struct {
+			SoftwareVersion string `json:"sw_ver"`
+			HardwareVersion string `json:"hw_ver"`
+			Model string `json:"model"`
+			DeviceIdentifier string `json:"deviceId"`
+			OEMIdentifier string `json:"oemId"`
+			HardwareIdentifier string `json:"hwId"`
+			SignalStrength int `json:"rssi"`
+			Latitude int `json:"latitude_i"`
+			Longitude int `json:"longitude_i"`
+			Alias string `json:"alias"`
+			Status string `json:"status"`
+			Source string `json:"obd_src"`
+			Type string `json:"mic_type"`
+			LegacyType string `json:"type"` // on older firmware, in place of mic_type
+			Features string `json:"feature"`
+			MACAddress string `json:"mac"`
+			Updating int `json:"updating"`
+			LEDOff int `json:"led_off"`
+			RelayState int `json:"relay_state"`
+			UptimeSeconds int `json:"on_time"`
+			IconHash string `json:"icon_hash"`
+			DeviceName string `json:"dev_name"`
+			ActiveMode string `json:"active_mode"`
+			NextAction struct {
+				Type int `json:"type"`
+				Identifier string `json:"id"`
+				ScheduledSeconds int `json:"schd_sec"`
+				Action int `json:"action"`
+			} `json:"next_action"`
+			NTCState int `json:"ntc_state"`
+			Children []struct {
+				Identifier string `json:"id"`
+				State int `json:"state"`
+				Alias string `json:"alias"`
+				UptimeSeconds int `json:"on_time"`
+				NextAction struct {
+					Type int `json:"type"`
+					Identifier string `json:"id"`
+					ScheduledSeconds int `json:"schd_sec"`
+					Action int `json:"action"`
+				} `json:"next_action"`
+			} `json:"children"` // outlets, on power strips
+			ChildCount int `json:"child_num"`
+			Brightness int `json:"brightness"` // percent, on dimmer switches
+			ErrorCode int `json:"err_code"`
+			ErrorMessage string `json:"err_msg"`
+		} `json:"get_sysinfo"`
+
+		RelayState struct {
+			ErrorCode int `json:"err_code"`
+			ErrorMessage string `json:"err_msg"`
+		} `json:"set_relay_state"`
+
+		LEDOff struct {
+			ErrorCode int `json:"err_code"`
+			ErrorMessage string `json:"err_msg"`
+		} `json:"set_led_off"`
+	} `json:"system"`
+
+	Time struct {
+		Now struct {
+			Year int `json:"year"`
+			Month int `json:"month"`
+			Day int `json:"mday"`
+			Hour int `json:"hour"`
+			Minute int `json:"min"`
+			Second int `json:"sec"`
+			ErrorCode int `json:"err_code"`
+			ErrorMessage string `json:"err_msg"`
+		} `json:"get_time"`
+
+		Zone struct {
+			Index int `json:"index"`
+			ErrorCode int `json:"err_code"`
+			ErrorMessage string `json:"err_msg"`
+		} `json:"get_timezone"`
+	} `json:"time"`
+
+	EnergyMeter struct {
+		Now struct {
+			Amperage int `json:"current_ma"` // milliamps
+			Voltage int `json:"voltage_mv"` // millivolts
+			Wattage int `json:"power_mw"` // milliwatts
+			Total int `json:"total_wh"` // watthours
+			LegacyAmperage *float64 `json:"current"` // amps, on older firmware
+			LegacyVoltage *float64 `json:"voltage"` // volts, on older firmware
+			LegacyWattage *float64 `json:"power"` // watts, on older firmware
+			LegacyTotal *float64 `json:"total"` // kilowatt-hours, on older firmware
+			ErrorCode int `json:"err_code"`
+			ErrorMessage string `json:"err_msg"`
+		} `json:"get_realtime"`
+
+		Daily struct {
+			Days []struct {
+				Year int `json:"year"`
+				Month int `json:"month"`
+				Day int `json:"day"`
+				Total int `json:"energy_wh"` // watthours
+				LegacyTotal *float64 `json:"energy"` // kilowatt-hours, on older firmware
+			} `json:"day_list"`
+			ErrorCode int `json:"err_code"`
+			ErrorMessage string `json:"err_msg"`
+		} `json:"get_daystat"`
+
+		Monthly struct {
+			Months []struct {
+				Year int `json:"year"`
+				Month int `json:"month"`
+				Total int `json:"energy_wh"` // watthours
+				LegacyTotal *float64 `json:"energy"` // kilowatt-hours, on older firmware
+			} `json:"month_list"`
+			ErrorCode int `json:"err_code"`
+			ErrorMessage string `json:"err_msg"`
+		} `json:"get_monthstat"`
+
+		Gains struct {
+			VoltageGain int `json:"vgain"`
+			CurrentGain int `json:"igain"`
+			ErrorCode int `json:"err_code"`
+			ErrorMessage string `json:"err_msg"`
+		} `json:"get_vgain_igain"`
+	} `json:"emeter"`
+}
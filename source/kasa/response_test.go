@@ -0,0 +1,37 @@
+package kasa
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// Parsing arbitrary responses must never panic, & must give a result for every method in the request when it succeeds
+func FuzzParseResponse( f *testing.F ) {
+	f.Add( []byte( `{"system":{"get_sysinfo":{"alias":"Plug","relay_state":1,"children":[{"id":"00","state":1}],"err_code":0}},"emeter":{"get_realtime":{"power_mw":1000,"err_code":0}},"time":{"err_code":-1,"err_msg":"module not support"}}` ) )
+	f.Add( []byte( `{"emeter":{"get_realtime":{"power":1.5,"total":0.2,"err_code":0}}}` ) )
+	f.Add( []byte( `{"system":null}` ) )
+	f.Add( []byte( `[]` ) )
+
+	f.Fuzz( func( t *testing.T, responsePayload []byte ) {
+		request := NewRequest().Add( "system", "get_sysinfo", nil ).Add( "emeter", "get_realtime", nil ).Add( "time", "get_time", nil )
+
+		// Parse the response into results, then decode each one like the client does
+		response, parseError := parseResponse( request, responsePayload )
+		if ( parseError == nil ) {
+			if ( len( response.Results() ) != 3 ) {
+				t.Fatalf( "expected 3 results, got %d", len( response.Results() ) )
+			}
+
+			var queryResponse QueryResponse
+			response.Decode( "system", "get_sysinfo", &queryResponse.System.Info )
+			response.Decode( "emeter", "get_realtime", &queryResponse.EnergyMeter.Now )
+			response.Decode( "time", "get_time", &queryResponse.Time.Now )
+		}
+
+		// Decode the whole response like the single query helper does
+		var queryResponse QueryResponse
+		json.Unmarshal( responsePayload, &queryResponse )
+		client := &Client{}
+		client.smartPlugFromResponse( queryResponse )
+	} )
+}
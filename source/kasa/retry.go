@@ -0,0 +1,105 @@
+package kasa
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"time"
+)
+
+// Structure for holding how a client reconnects & retries when the connection drops
+type RetryPolicy struct {
+
+	// The maximum number of attempts for each query, including the first, so one disables retrying
+	MaxAttempts int
+
+	// The delay before the first retry, doubling with each retry up to the maximum
+	InitialBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// Whether to resend queries that change the smart plug (e.g., set_relay_state) if the connection drops after sending,
+	// which could apply them twice. Queries that only read (e.g., get_sysinfo) are always resent.
+	RetryMutations bool
+
+}
+
+// Returns the default retry policy, which resends read-only queries up to twice
+func DefaultRetryPolicy() ( RetryPolicy ) {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		InitialBackoff: 250 * time.Millisecond,
+		MaxBackoff: 5 * time.Second,
+		RetryMutations: false,
+	}
+}
+
+// Sleeps for the exponential backoff with jitter after the given attempt, or until the context is finished, which is a timeout if its deadline passed
+func ( policy RetryPolicy ) wait( ctx context.Context, attempt int ) ( error ) {
+
+	// Double the delay for each attempt, up to the maximum
+	backoff := policy.InitialBackoff
+	for retry := 1; retry < attempt && backoff < policy.MaxBackoff; retry++ {
+		backoff *= 2
+	}
+	if ( backoff > policy.MaxBackoff ) {
+		backoff = policy.MaxBackoff
+	}
+
+	// Randomise the second half of the delay, so many clients do not retry in lockstep
+	if ( backoff > 1 ) {
+		backoff = ( backoff / 2 ) + rand.N( backoff / 2 )
+	}
+
+	// Wait for the delay or the context, whichever finishes first
+	timer := time.NewTimer( backoff )
+	defer timer.Stop()
+	select {
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			return asTimeoutError( ctx, ctx.Err() )
+	}
+
+}
+
+// Checks if an error means the connection dropped or could not be opened, rather than a timeout or a finished context
+func isConnectionError( originalError error ) ( bool ) {
+
+	// Timeouts & finished contexts are never retried
+	if ( errors.Is( originalError, ErrTimeout ) || errors.Is( originalError, context.Canceled ) || errors.Is( originalError, context.DeadlineExceeded ) ) {
+		return false
+	}
+
+	// The smart plug closed the connection
+	if ( errors.Is( originalError, io.EOF ) || errors.Is( originalError, io.ErrUnexpectedEOF ) || errors.Is( originalError, net.ErrClosed ) ) {
+		return true
+	}
+
+	// The connection was reset, refused, or otherwise failed at the network level
+	var operationError *net.OpError
+	return errors.As( originalError, &operationError )
+
+}
+
+// How long a connection can be idle before checking it is still open, as smart plugs close idle connections but checking delays every query
+const idleCheckAfter = time.Second
+
+// Checks if an idle connection is still open, by briefly reading from it without expecting anything
+func isConnectionAlive( connection net.Conn ) ( bool ) {
+
+	// Only wait a moment, as a closed connection reads immediately
+	deadlineError := connection.SetReadDeadline( time.Now().Add( time.Millisecond ) )
+	if ( deadlineError != nil ) {
+		return false
+	}
+	defer connection.SetReadDeadline( time.Time{} )
+
+	// The connection is alive if nothing arrives before the deadline, whereas end-of-file or unexpected data means it is unusable
+	var probe [ 1 ]byte
+	_, readError := connection.Read( probe[ : ] )
+	var networkError net.Error
+	return ( errors.As( readError, &networkError ) && networkError.Timeout() )
+
+}
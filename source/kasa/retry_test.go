@@ -0,0 +1,74 @@
+package kasa
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// Returns a port on the loopback address with nothing listening on it
+func closedPort( t *testing.T ) ( *net.TCPAddr ) {
+	listener, listenError := net.Listen( "tcp4", "127.0.0.1:0" )
+	if ( listenError != nil ) {
+		t.Fatal( listenError )
+	}
+	defer listener.Close()
+
+	return listener.Addr().( *net.TCPAddr )
+}
+
+// A deadline that passes while waiting for another query to finish must be a timeout
+func TestDeadlineDuringLockContention( t *testing.T ) {
+	address := closedPort( t )
+	client := NewClient( address.IP, address.Port )
+
+	// Hold the lock as if another query was in progress
+	acquireError := client.acquire( context.Background() )
+	if ( acquireError != nil ) {
+		t.Fatal( acquireError )
+	}
+	defer client.release()
+
+	ctx, cancel := context.WithTimeout( context.Background(), 50 * time.Millisecond )
+	defer cancel()
+	_, queryError := client.GetSystemInformation( ctx )
+	if ( !errors.Is( queryError, ErrTimeout ) || !errors.Is( queryError, context.DeadlineExceeded ) ) {
+		t.Errorf( "expected a timeout, got %v", queryError )
+	}
+}
+
+// A deadline that passes while backing off before reconnecting must be a timeout
+func TestDeadlineDuringBackoff( t *testing.T ) {
+	address := closedPort( t )
+	client := NewClient( address.IP, address.Port )
+	client.Retry = RetryPolicy{ MaxAttempts: 5, InitialBackoff: 10 * time.Second, MaxBackoff: 10 * time.Second }
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout( context.Background(), 100 * time.Millisecond )
+	defer cancel()
+	startedAt := time.Now()
+	_, queryError := client.GetSystemInformation( ctx )
+	if ( !errors.Is( queryError, ErrTimeout ) || !errors.Is( queryError, context.DeadlineExceeded ) ) {
+		t.Errorf( "expected a timeout, got %v", queryError )
+	}
+	if ( time.Since( startedAt ) > 5 * time.Second ) {
+		t.Errorf( "expected the deadline to end the backoff early, took %s", time.Since( startedAt ) )
+	}
+}
+
+// A cancelled context must not be a timeout
+func TestCancelDuringBackoff( t *testing.T ) {
+	address := closedPort( t )
+	client := NewClient( address.IP, address.Port )
+	client.Retry = RetryPolicy{ MaxAttempts: 5, InitialBackoff: 10 * time.Second, MaxBackoff: 10 * time.Second }
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel( context.Background() )
+	time.AfterFunc( 50 * time.Millisecond, cancel )
+	_, queryError := client.GetSystemInformation( ctx )
+	if ( errors.Is( queryError, ErrTimeout ) || !errors.Is( queryError, context.Canceled ) ) {
+		t.Errorf( "expected a cancellation, got %v", queryError )
+	}
+}
@@ -0,0 +1,95 @@
+package kasa
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// The schedule, count_down & anti_theft modules share the same methods for managing their rules, differing only in the fields of each rule
+
+// Calls a method of one of the rule modules, failing first if the smart plug does not have them
+func ( client *Client ) callRules( ctx context.Context, moduleName string, methodName string, arguments any, result any ) ( error ) {
+	requireError := client.require( ctx, CapabilityTimer )
+	if ( requireError != nil ) {
+		return requireError
+	}
+
+	return client.call( ctx, moduleName, methodName, arguments, result )
+}
+
+// Get the rules of a module, parsing them into the given slice pointer, & whether the rules are enabled overall
+func ( client *Client ) getRules( ctx context.Context, moduleName string, rules any ) ( bool, error ) {
+
+	// Fetch the rules
+	var result struct {
+		Rules json.RawMessage `json:"rule_list"`
+		Enabled *int `json:"enable"`
+	}
+	callError := client.callRules( ctx, moduleName, "get_rules", nil, &result )
+	if ( callError != nil ) {
+		return false, callError
+	}
+
+	// Parse the rules, which are missing when there are none
+	if ( len( result.Rules ) > 0 ) {
+		decodeError := json.Unmarshal( result.Rules, rules )
+		if ( decodeError != nil ) {
+			return false, fmt.Errorf( "%w: rules of module '%s': %w", ErrInvalidResponse, moduleName, decodeError )
+		}
+	}
+
+	// Rules are enabled overall unless stated otherwise
+	return ( result.Enabled == nil || *result.Enabled != 0 ), nil
+
+}
+
+// Adds a rule to a module & returns the identifier that the smart plug assigned to it
+func ( client *Client ) addRule( ctx context.Context, moduleName string, rule any ) ( string, error ) {
+
+	// Send the rule
+	var result struct {
+		Identifier string `json:"id"`
+	}
+	callError := client.callRules( ctx, moduleName, "add_rule", rule, &result )
+	if ( callError != nil ) {
+		return "", callError
+	}
+
+	// Return the identifier
+	return result.Identifier, nil
+
+}
+
+// Replaces an existing rule of a module, which is found by the identifier within the rule
+func ( client *Client ) editRule( ctx context.Context, moduleName string, rule any ) ( error ) {
+	return client.callRules( ctx, moduleName, "edit_rule", rule, nil )
+}
+
+// Removes a rule from a module
+func ( client *Client ) deleteRule( ctx context.Context, moduleName string, identifier string ) ( error ) {
+	return client.callRules( ctx, moduleName, "delete_rule", map[string]string {
+		"id": identifier,
+	}, nil )
+}
+
+// Removes every rule from a module
+func ( client *Client ) deleteAllRules( ctx context.Context, moduleName string ) ( error ) {
+	return client.callRules( ctx, moduleName, "delete_all_rules", nil, nil )
+}
+
+// Enables or disables all the rules of a module, without changing whether each rule is enabled
+func ( client *Client ) setRulesEnabled( ctx context.Context, moduleName string, enabled bool ) ( error ) {
+	return client.callRules( ctx, moduleName, "set_overall_enable", map[string]int {
+		"enable": boolToInt( enabled ),
+	}, nil )
+}
+
+// Converts a boolean to the integer the smart plug uses for one
+func boolToInt( value bool ) ( int ) {
+	if ( value ) {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,230 @@
+package kasa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// What the time of a schedule rule is relative to
+type TimeReference int
+
+const (
+	TimeOfDay TimeReference = 0
+	Sunrise TimeReference = 1
+	Sunset TimeReference = 2
+)
+
+// Structure for holding a rule that switches the power relay on or off at a time of day, on certain days of the week
+type ScheduleRule struct {
+	Identifier string // Assigned by the smart plug
+	Name string
+	Enabled bool
+
+	// The days of the week to repeat on, starting from Sunday
+	Weekdays [ 7 ]bool
+
+	// When to switch, either minutes after midnight, or minutes before (negative) or after sunrise or sunset
+	Reference TimeReference
+	Minutes int
+	Offset int
+
+	// Whether to switch on or off
+	PowerState bool
+}
+
+// Structure for a schedule rule as sent & received by the smart plug
+type scheduleRuleJSON struct {
+	Identifier string `json:"id,omitempty"`
+	Name string `json:"name"`
+	Enable int `json:"enable"`
+	Weekdays [ 7 ]int `json:"wday"`
+	Repeat int `json:"repeat"`
+	StartReference int `json:"stime_opt"`
+	StartMinutes int `json:"smin"`
+	StartOffset int `json:"soffset"`
+	StartAction int `json:"sact"`
+	EndReference int `json:"etime_opt"`
+	EndMinutes int `json:"emin"`
+	EndAction int `json:"eact"`
+	Year int `json:"year"`
+	Month int `json:"month"`
+	Day int `json:"day"`
+	Longitude int `json:"longitude"`
+	Latitude int `json:"latitude"`
+}
+
+// Checks the rule can be sent to the smart plug
+func ( rule ScheduleRule ) validate() ( error ) {
+
+	// Require at least one day, as one-off rules need a date that only the smart plug knows
+	weekdaysError := validateWeekdays( rule.Weekdays )
+	if ( weekdaysError != nil ) {
+		return weekdaysError
+	}
+
+	// Require a valid time
+	return validateRuleTime( rule.Reference, rule.Minutes, rule.Offset )
+
+}
+
+// Checks that a rule repeats on at least one day of the week
+func validateWeekdays( weekdays [ 7 ]bool ) ( error ) {
+	for _, weekday := range weekdays {
+		if ( weekday ) {
+			return nil
+		}
+	}
+
+	return errors.New( "rule must repeat on at least one day of the week" )
+}
+
+// Checks that a rule time has a known reference & is within a day
+func validateRuleTime( reference TimeReference, minutes int, offset int ) ( error ) {
+
+	// Require a known time reference
+	if ( reference != TimeOfDay && reference != Sunrise && reference != Sunset ) {
+		return fmt.Errorf( "unknown rule time reference %d", reference )
+	}
+
+	// Require a time within the day
+	if ( minutes < 0 || minutes >= 24 * 60 ) {
+		return fmt.Errorf( "invalid rule time of %d minutes, must be between 0 and 1439", minutes )
+	}
+	if ( offset <= -24 * 60 || offset >= 24 * 60 ) {
+		return fmt.Errorf( "invalid rule offset of %d minutes, must be within a day", offset )
+	}
+
+	// Return no error
+	return nil
+
+}
+
+// Converts the days of the week to how the smart plug expects them
+func weekdaysToJSON( weekdays [ 7 ]bool ) ( [ 7 ]int ) {
+	weekdaysJSON := [ 7 ]int{}
+	for index, weekday := range weekdays {
+		weekdaysJSON[ index ] = boolToInt( weekday )
+	}
+
+	return weekdaysJSON
+}
+
+// Converts the days of the week from how the smart plug sent them
+func weekdaysFromJSON( weekdaysJSON [ 7 ]int ) ( [ 7 ]bool ) {
+	weekdays := [ 7 ]bool{}
+	for index, weekday := range weekdaysJSON {
+		weekdays[ index ] = ( weekday != 0 )
+	}
+
+	return weekdays
+}
+
+// Converts the rule to how the smart plug expects it
+func ( rule ScheduleRule ) toJSON() ( scheduleRuleJSON ) {
+	ruleJSON := scheduleRuleJSON{
+		Identifier: rule.Identifier,
+		Name: rule.Name,
+		Enable: boolToInt( rule.Enabled ),
+		Repeat: 1,
+		StartReference: int( rule.Reference ),
+		StartMinutes: rule.Minutes,
+		StartAction: boolToInt( rule.PowerState ),
+		EndReference: -1, // No end action
+		EndAction: -1,
+	}
+
+	// The offset only applies to sunrise & sunset
+	if ( rule.Reference != TimeOfDay ) {
+		ruleJSON.StartOffset = rule.Offset
+	}
+
+	ruleJSON.Weekdays = weekdaysToJSON( rule.Weekdays )
+
+	return ruleJSON
+}
+
+// Converts the rule from how the smart plug sent it
+func ( ruleJSON scheduleRuleJSON ) toRule() ( ScheduleRule ) {
+	rule := ScheduleRule{
+		Identifier: ruleJSON.Identifier,
+		Name: ruleJSON.Name,
+		Enabled: ( ruleJSON.Enable != 0 ),
+		Reference: TimeReference( ruleJSON.StartReference ),
+		Minutes: ruleJSON.StartMinutes,
+		Offset: ruleJSON.StartOffset,
+		PowerState: ( ruleJSON.StartAction == 1 ),
+	}
+
+	rule.Weekdays = weekdaysFromJSON( ruleJSON.Weekdays )
+
+	return rule
+}
+
+// Get the schedule rules, & whether the schedule is enabled overall
+func ( client *Client ) GetScheduleRules( ctx context.Context ) ( []ScheduleRule, bool, error ) {
+
+	// Fetch the rules
+	var rulesJSON []scheduleRuleJSON
+	enabled, rulesError := client.getRules( ctx, "schedule", &rulesJSON )
+	if ( rulesError != nil ) {
+		return nil, false, rulesError
+	}
+
+	// Convert each rule
+	rules := make( []ScheduleRule, len( rulesJSON ) )
+	for index, ruleJSON := range rulesJSON {
+		rules[ index ] = ruleJSON.toRule()
+	}
+
+	// Return the rules
+	return rules, enabled, nil
+
+}
+
+// Adds a schedule rule & returns the identifier that the smart plug assigned to it
+func ( client *Client ) AddScheduleRule( ctx context.Context, rule ScheduleRule ) ( string, error ) {
+
+	// Fail if the rule is invalid
+	validateError := rule.validate()
+	if ( validateError != nil ) {
+		return "", validateError
+	}
+
+	// Add the rule, without an identifier
+	rule.Identifier = ""
+	return client.addRule( ctx, "schedule", rule.toJSON() )
+
+}
+
+// Replaces an existing schedule rule with the same identifier
+func ( client *Client ) EditScheduleRule( ctx context.Context, rule ScheduleRule ) ( error ) {
+
+	// Fail if the rule is invalid or does not say which rule to replace
+	if ( rule.Identifier == "" ) {
+		return errors.New( "schedule rule has no identifier" )
+	}
+	validateError := rule.validate()
+	if ( validateError != nil ) {
+		return validateError
+	}
+
+	// Replace the rule
+	return client.editRule( ctx, "schedule", rule.toJSON() )
+
+}
+
+// Removes a schedule rule
+func ( client *Client ) DeleteScheduleRule( ctx context.Context, identifier string ) ( error ) {
+	return client.deleteRule( ctx, "schedule", identifier )
+}
+
+// Removes every schedule rule
+func ( client *Client ) DeleteAllScheduleRules( ctx context.Context ) ( error ) {
+	return client.deleteAllRules( ctx, "schedule" )
+}
+
+// Enables or disables the schedule overall, without changing whether each rule is enabled
+func ( client *Client ) SetScheduleEnabled( ctx context.Context, enabled bool ) ( error ) {
+	return client.setRulesEnabled( ctx, "schedule", enabled )
+}
@@ -0,0 +1,107 @@
+package kasa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Structure for holding a snapshot of the data about an outlet on a power strip, such as the HS300 or KP303
+type Outlet struct {
+
+	// The full identifier, for addressing queries with Client.Outlet
+	Identifier string
+
+	// Runtime & state
+	Alias string
+	PowerState bool
+	Uptime int
+
+	// Current action
+	Action Action
+
+	// Energy usage, only on power strips with an energy meter for each outlet
+	Energy EnergyUsage
+
+}
+
+// Returns the full identifier of an outlet, as some power strips only report the last two digits
+func outletIdentifier( deviceIdentifier string, childIdentifier string ) ( string ) {
+	if ( len( childIdentifier ) == 1 ) {
+		return deviceIdentifier + "0" + childIdentifier
+	} else if ( len( childIdentifier ) == 2 ) {
+		return deviceIdentifier + childIdentifier
+	}
+
+	return childIdentifier
+}
+
+// Fetches the outlets of a power strip, which is empty for anything else
+func ( client *Client ) GetOutlets( ctx context.Context ) ( []Outlet, error ) {
+
+	// Fetch the system information, which includes the outlets
+	smartPlug, infoError := client.GetSystemInformation( ctx )
+	if ( infoError != nil ) {
+		return nil, infoError
+	}
+
+	// Return the outlets
+	return smartPlug.Outlets, nil
+
+}
+
+// Adds up the energy usage of the outlets, for power strips that only measure each outlet, using the highest voltage as they share the same supply
+func sumEnergyUsage( outlets []Outlet ) ( EnergyUsage ) {
+	total := EnergyUsage{}
+	for _, outlet := range outlets {
+		total.Amperage += outlet.Energy.Amperage
+		total.Wattage += outlet.Energy.Wattage
+		total.Total += outlet.Energy.Total
+		total.Voltage = max( total.Voltage, outlet.Energy.Voltage )
+	}
+
+	return total
+}
+
+// Fetches the energy usage of each outlet, leaving it empty if the power strip has no energy meter
+func ( client *Client ) getOutletEnergyUsage( ctx context.Context, outlets []Outlet ) ( error ) {
+	for index := range outlets {
+		energyUsage, energyUsageError := client.Outlet( outlets[ index ].Identifier ).GetEnergyUsage( ctx )
+		if ( errors.Is( energyUsageError, ErrModuleNotSupported ) ) {
+			return nil
+		} else if ( energyUsageError != nil ) {
+			return energyUsageError
+		}
+
+		outlets[ index ].Energy = energyUsage
+	}
+
+	return nil
+}
+
+// Finds an outlet by its position (starting at 1), alias or identifier
+func FindOutlet( outlets []Outlet, name string ) ( Outlet, error ) {
+
+	// Try the position first, as it is the shortest to type
+	position, parseError := strconv.Atoi( name )
+	if ( parseError == nil ) {
+		if ( position < 1 || position > len( outlets ) ) {
+			return Outlet{}, fmt.Errorf( "no outlet at position %d, there are %d", position, len( outlets ) )
+		}
+
+		return outlets[ position - 1 ], nil
+	}
+
+	// Otherwise, match the alias ignoring case, or the identifier
+	for _, outlet := range outlets {
+		if ( strings.EqualFold( outlet.Alias, name ) || outlet.Identifier == name ) {
+			return outlet, nil
+		}
+	}
+
+	// Fail if nothing matched
+	return Outlet{}, fmt.Errorf( "no outlet named '%s'", name )
+
+}
@@ -0,0 +1,295 @@
+package kasa
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// The IANA timezones for each timezone index used by the smart plug, which follows the order of the Windows timezone list.
+// Daylight saving time is handled by the time package, as the smart plug only stores the index.
+var timezoneNames = [...]string{
+	0: "Etc/GMT+12", // International Date Line West
+	1: "Pacific/Pago_Pago", // Samoa
+	2: "Pacific/Honolulu", // Hawaii
+	3: "America/Anchorage", // Alaska
+	4: "America/Tijuana", // Baja California
+	5: "Etc/GMT+8", // Coordinated Universal Time-08
+	6: "America/Los_Angeles", // Pacific Time (US & Canada)
+	7: "America/Phoenix", // Arizona
+	8: "America/Mazatlan", // Chihuahua, La Paz, Mazatlan
+	9: "Etc/GMT+7", // Mountain Standard Time
+	10: "America/Denver", // Mountain Time (US & Canada)
+	11: "America/Mexico_City", // Guadalajara, Mexico City
+	12: "Etc/GMT+6", // Central America
+	13: "America/Chicago", // Central Time (US & Canada)
+	14: "America/Monterrey", // Monterrey
+	15: "America/Regina", // Saskatchewan
+	16: "America/Bogota", // Bogota, Lima, Quito
+	17: "America/New_York", // Eastern Time (US & Canada)
+	18: "America/Indiana/Indianapolis", // Indiana (East)
+	19: "America/Caracas", // Caracas
+	20: "America/Asuncion", // Asuncion
+	21: "Etc/GMT+4", // Coordinated Universal Time-04
+	22: "America/Halifax", // Atlantic Time (Canada)
+	23: "America/Cuiaba", // Cuiaba
+	24: "America/Manaus", // Georgetown, La Paz, Manaus
+	25: "America/Santiago", // Santiago
+	26: "America/St_Johns", // Newfoundland
+	27: "America/Sao_Paulo", // Brasilia
+	28: "America/Argentina/Buenos_Aires", // Buenos Aires
+	29: "America/Cayenne", // Cayenne, Fortaleza
+	30: "America/Miquelon", // Saint Pierre & Miquelon
+	31: "America/Montevideo", // Montevideo
+	32: "America/Santiago", // Chile (Continental)
+	33: "Etc/GMT+2", // Coordinated Universal Time-02
+	34: "Atlantic/Azores", // Azores
+	35: "Atlantic/Cape_Verde", // Cape Verde
+	36: "Africa/Casablanca", // Casablanca
+	37: "Etc/UTC", // Coordinated Universal Time
+	38: "Europe/London", // Dublin, Edinburgh, Lisbon, London
+	39: "Africa/Monrovia", // Monrovia, Reykjavik
+	40: "Europe/Amsterdam", // Amsterdam, Berlin, Bern, Rome, Stockholm, Vienna
+	41: "Europe/Belgrade", // Belgrade, Bratislava, Budapest, Ljubljana, Prague
+	42: "Europe/Brussels", // Brussels, Copenhagen, Madrid, Paris
+	43: "Europe/Sarajevo", // Sarajevo, Skopje, Warsaw, Zagreb
+	44: "Africa/Lagos", // West Central Africa
+	45: "Africa/Windhoek", // Windhoek
+	46: "Asia/Amman", // Amman
+	47: "Europe/Athens", // Athens, Bucharest
+	48: "Asia/Beirut", // Beirut
+	49: "Africa/Cairo", // Cairo
+	50: "Asia/Damascus", // Damascus
+	51: "Europe/Chisinau", // Eastern Europe
+	52: "Africa/Harare", // Harare, Pretoria
+	53: "Europe/Helsinki", // Helsinki, Kyiv, Riga, Sofia, Tallinn, Vilnius
+	54: "Europe/Istanbul", // Istanbul
+	55: "Asia/Jerusalem", // Jerusalem
+	56: "Europe/Kaliningrad", // Kaliningrad
+	57: "Africa/Tripoli", // Tripoli
+	58: "Asia/Baghdad", // Baghdad
+	59: "Asia/Kuwait", // Kuwait, Riyadh
+	60: "Europe/Minsk", // Minsk
+	61: "Europe/Moscow", // Moscow, St. Petersburg, Volgograd
+	62: "Africa/Nairobi", // Nairobi
+	63: "Asia/Tehran", // Tehran
+	64: "Asia/Muscat", // Abu Dhabi, Muscat
+	65: "Asia/Baku", // Baku
+	66: "Europe/Samara", // Izhevsk, Samara
+	67: "Indian/Mauritius", // Port Louis
+	68: "Asia/Tbilisi", // Tbilisi
+	69: "Asia/Yerevan", // Yerevan
+	70: "Asia/Kabul", // Kabul
+	71: "Asia/Ashgabat", // Ashgabat, Tashkent
+	72: "Asia/Yekaterinburg", // Ekaterinburg
+	73: "Asia/Karachi", // Islamabad, Karachi
+	74: "Asia/Kolkata", // Chennai, Kolkata, Mumbai, New Delhi
+	75: "Asia/Colombo", // Sri Jayawardenepura
+	76: "Asia/Kathmandu", // Kathmandu
+	77: "Asia/Almaty", // Astana
+	78: "Asia/Dhaka", // Dhaka
+	79: "Asia/Novosibirsk", // Novosibirsk
+	80: "Asia/Yangon", // Yangon (Rangoon)
+	81: "Asia/Bangkok", // Bangkok, Hanoi, Jakarta
+	82: "Asia/Krasnoyarsk", // Krasnoyarsk
+	83: "Asia/Shanghai", // Beijing, Chongqing, Hong Kong, Urumqi
+	84: "Asia/Irkutsk", // Irkutsk
+	85: "Asia/Singapore", // Kuala Lumpur, Singapore
+	86: "Australia/Perth", // Perth
+	87: "Asia/Taipei", // Taipei
+	88: "Asia/Ulaanbaatar", // Ulaanbaatar
+	89: "Asia/Tokyo", // Osaka, Sapporo, Tokyo
+	90: "Asia/Seoul", // Seoul
+	91: "Asia/Yakutsk", // Yakutsk
+	92: "Australia/Adelaide", // Adelaide
+	93: "Australia/Darwin", // Darwin
+	94: "Australia/Brisbane", // Brisbane
+	95: "Australia/Sydney", // Canberra, Melbourne, Sydney
+	96: "Pacific/Guam", // Guam, Port Moresby
+	97: "Australia/Hobart", // Hobart
+	98: "Antarctica/DumontDUrville", // Dumont d'Urville
+	99: "Asia/Magadan", // Magadan
+	100: "Asia/Srednekolymsk", // Chokurdakh
+	101: "Etc/GMT-11", // Solomon Islands, New Caledonia
+	102: "Asia/Anadyr", // Anadyr, Petropavlovsk-Kamchatsky
+	103: "Pacific/Auckland", // Auckland, Wellington
+	104: "Etc/GMT-12", // Coordinated Universal Time+12
+	105: "Pacific/Fiji", // Fiji
+	106: "Etc/GMT-13", // Coordinated Universal Time+13
+	107: "Asia/Kamchatka", // Petropavlovsk-Kamchatsky
+	108: "Pacific/Tongatapu", // Nuku'alofa
+	109: "Etc/GMT-14", // Kiritimati Island
+}
+
+// The timezone index for other common IANA timezones, as several Windows timezones share the same offsets & only the name tells them apart
+var timezoneAliases = map[string]int {
+	"UTC": 37,
+	"GMT": 37,
+	"Etc/GMT": 37,
+	"America/Vancouver": 6,
+	"America/Edmonton": 10,
+	"America/Boise": 10,
+	"America/Winnipeg": 13,
+	"America/Toronto": 17,
+	"America/Detroit": 17,
+	"Europe/Dublin": 38,
+	"Europe/Lisbon": 38,
+	"Atlantic/Reykjavik": 39,
+	"Europe/Berlin": 40,
+	"Europe/Zurich": 40,
+	"Europe/Rome": 40,
+	"Europe/Stockholm": 40,
+	"Europe/Vienna": 40,
+	"Europe/Oslo": 40,
+	"Europe/Luxembourg": 40,
+	"Europe/Bratislava": 41,
+	"Europe/Budapest": 41,
+	"Europe/Ljubljana": 41,
+	"Europe/Prague": 41,
+	"Europe/Paris": 42,
+	"Europe/Madrid": 42,
+	"Europe/Copenhagen": 42,
+	"Europe/Warsaw": 43,
+	"Europe/Skopje": 43,
+	"Europe/Zagreb": 43,
+	"Europe/Bucharest": 47,
+	"Africa/Johannesburg": 52,
+	"Europe/Kyiv": 53,
+	"Europe/Kiev": 53,
+	"Europe/Riga": 53,
+	"Europe/Sofia": 53,
+	"Europe/Tallinn": 53,
+	"Europe/Vilnius": 53,
+	"Asia/Riyadh": 59,
+	"Asia/Dubai": 64,
+	"Asia/Calcutta": 74,
+	"Asia/Ho_Chi_Minh": 81,
+	"Asia/Jakarta": 81,
+	"Asia/Hong_Kong": 83,
+	"Asia/Kuala_Lumpur": 85,
+	"Australia/Melbourne": 95,
+	"Australia/Canberra": 95,
+}
+
+// Returns the location for a timezone index used by the smart plug
+func TimezoneLocation( index int ) ( *time.Location, error ) {
+
+	// Fail if the index is not in the table
+	if ( index < 0 || index >= len( timezoneNames ) ) {
+		return nil, fmt.Errorf( "unknown timezone index %d", index )
+	}
+
+	// Load the location, which requires the timezone database on the system or embedded with time/tzdata
+	return time.LoadLocation( timezoneNames[ index ] )
+
+}
+
+// Get the timezone index & its location
+func ( client *Client ) GetTimezone( ctx context.Context ) ( int, *time.Location, error ) {
+
+	// Fetch the timezone
+	queryResponse, queryError := client.SendQuery( ctx, "time", "get_timezone", nil )
+	if ( queryError != nil ) {
+		return 0, nil, queryError
+	}
+
+	// Convert the index to a location
+	location, locationError := TimezoneLocation( queryResponse.Time.Zone.Index )
+	if ( locationError != nil ) {
+		return queryResponse.Time.Zone.Index, nil, locationError
+	}
+
+	// Return the index & location
+	return queryResponse.Time.Zone.Index, location, nil
+
+}
+
+// Returns the timezone index used by the smart plug for a location, matching by name or otherwise by the same offsets throughout the year
+func TimezoneIndex( location *time.Location ) ( int, error ) {
+
+	// Prefer an exact match on the name
+	for index, name := range timezoneNames {
+		if ( name == location.String() ) {
+			return index, nil
+		}
+	}
+
+	// Then try the other common names, as matching by offset cannot tell apart timezones like Paris & Berlin
+	aliasIndex, isAlias := timezoneAliases[ location.String() ]
+	if ( isAlias ) {
+		return aliasIndex, nil
+	}
+
+	// Otherwise, match a timezone that has the same offsets in winter & summer, so daylight saving time behaves the same
+	winter := time.Date( time.Now().Year(), time.January, 1, 12, 0, 0, 0, time.UTC )
+	summer := time.Date( time.Now().Year(), time.July, 1, 12, 0, 0, 0, time.UTC )
+	_, winterOffset := winter.In( location ).Zone()
+	_, summerOffset := summer.In( location ).Zone()
+	for index := range timezoneNames {
+		candidate, loadError := TimezoneLocation( index )
+		if ( loadError != nil ) {
+			continue
+		}
+
+		_, candidateWinterOffset := winter.In( candidate ).Zone()
+		_, candidateSummerOffset := summer.In( candidate ).Zone()
+		if ( candidateWinterOffset == winterOffset && candidateSummerOffset == summerOffset ) {
+			return index, nil
+		}
+	}
+
+	// Fail if nothing matched
+	return 0, fmt.Errorf( "no timezone index for location '%s'", location )
+
+}
+
+// Sets the timezone to the given location & the clock to the wall clock of the given time in that location, rounded to the nearest second
+func ( client *Client ) SetTimezone( ctx context.Context, location *time.Location, now time.Time ) ( error ) {
+
+	// Find the timezone index for the location
+	index, indexError := TimezoneIndex( location )
+	if ( indexError != nil ) {
+		return indexError
+	}
+
+	// Send the timezone command, which also sets the clock, rounding as the smart plug only takes whole seconds
+	wallClock := now.Round( time.Second ).In( location )
+	_, queryError := client.SendQuery( ctx, "time", "set_timezone", map[string]int {
+		"year": wallClock.Year(),
+		"month": int( wallClock.Month() ),
+		"mday": wallClock.Day(),
+		"hour": wallClock.Hour(),
+		"min": wallClock.Minute(),
+		"sec": wallClock.Second(),
+		"index": index,
+	} )
+	if ( queryError != nil ) {
+		return queryError
+	}
+
+	// Return no error
+	return nil
+
+}
+
+// Sets the clock to the wall clock of the given time, which should already be in the smart plug's timezone, rounded to the nearest second
+func ( client *Client ) SetTime( ctx context.Context, now time.Time ) ( error ) {
+
+	// Send the time command, rounding as the smart plug only takes whole seconds
+	now = now.Round( time.Second )
+	_, queryError := client.SendQuery( ctx, "time", "set_time", map[string]int {
+		"year": now.Year(),
+		"month": int( now.Month() ),
+		"mday": now.Day(),
+		"hour": now.Hour(),
+		"min": now.Minute(),
+		"sec": now.Second(),
+	} )
+	if ( queryError != nil ) {
+		return queryError
+	}
+
+	// Return no error
+	return nil
+
+}
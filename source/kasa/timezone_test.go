@@ -0,0 +1,52 @@
+package kasa
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// Timezones with the same offsets must be told apart by name, rather than matching the first with those offsets
+func TestTimezoneIndexAliases( t *testing.T ) {
+	for name, expectedIndex := range map[string]int {
+		"Europe/London": 38,
+		"Europe/Paris": 42,
+		"Europe/Madrid": 42,
+		"Europe/Berlin": 40,
+		"Europe/Warsaw": 43,
+		"Europe/Prague": 41,
+		"UTC": 37,
+	} {
+		location, loadError := time.LoadLocation( name )
+		if ( loadError != nil ) {
+			t.Skipf( "timezone database is unavailable: %v", loadError )
+		}
+
+		index, indexError := TimezoneIndex( location )
+		if ( indexError != nil || index != expectedIndex ) {
+			t.Errorf( "%s: expected index %d, got %d (%v)", name, expectedIndex, index, indexError )
+		}
+	}
+}
+
+// Every alias must point at a timezone in the table with the same offsets in winter & summer
+func TestTimezoneAliasesMatchOffsets( t *testing.T ) {
+	winter := time.Date( 2024, time.January, 1, 12, 0, 0, 0, time.UTC )
+	summer := time.Date( 2024, time.July, 1, 12, 0, 0, 0, time.UTC )
+
+	for name, index := range timezoneAliases {
+		alias, aliasLoadError := time.LoadLocation( name )
+		target, targetLoadError := TimezoneLocation( index )
+		if ( aliasLoadError != nil || targetLoadError != nil ) {
+			t.Skipf( "timezone database is unavailable: %v", errors.Join( aliasLoadError, targetLoadError ) )
+		}
+
+		_, aliasWinterOffset := winter.In( alias ).Zone()
+		_, aliasSummerOffset := summer.In( alias ).Zone()
+		_, targetWinterOffset := winter.In( target ).Zone()
+		_, targetSummerOffset := summer.In( target ).Zone()
+		if ( aliasWinterOffset != targetWinterOffset || aliasSummerOffset != targetSummerOffset ) {
+			t.Errorf( "%s: offsets do not match index %d (%s)", name, index, timezoneNames[ index ] )
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package kasa
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Structure for holding the energy used on a single day
+type DailyUsage struct {
+	Year int
+	Month time.Month
+	Day int
+	Total int // watthours
+}
+
+// Structure for holding the energy used in a single month
+type MonthlyUsage struct {
+	Year int
+	Month time.Month
+	Total int // watthours
+}
+
+// Get the energy used on each day of a month, only including days that the smart plug has recorded
+func ( client *Client ) GetDailyUsage( ctx context.Context, year int, month time.Month ) ( []DailyUsage, error ) {
+
+	// Fail if the smart plug has no energy meter
+	requireError := client.require( ctx, CapabilityEnergyMeter )
+	if ( requireError != nil ) {
+		return nil, requireError
+	}
+
+	// Send the daily statistics command
+	queryResponse, queryError := client.SendQuery( ctx, "emeter", "get_daystat", map[string]int {
+		"year": year,
+		"month": int( month ),
+	} )
+	if ( queryError != nil ) {
+		return nil, queryError
+	}
+
+	// Convert each day
+	dailyUsages := make( []DailyUsage, len( queryResponse.EnergyMeter.Daily.Days ) )
+	for index, day := range queryResponse.EnergyMeter.Daily.Days {
+		dailyUsages[ index ] = DailyUsage{
+			Year: day.Year,
+			Month: time.Month( day.Month ),
+			Day: day.Day,
+			Total: legacyWatthours( day.LegacyTotal, day.Total ),
+		}
+	}
+
+	// Return the days
+	return dailyUsages, nil
+
+}
+
+// Get the energy used in each month of a year, only including months that the smart plug has recorded
+func ( client *Client ) GetMonthlyUsage( ctx context.Context, year int ) ( []MonthlyUsage, error ) {
+
+	// Fail if the smart plug has no energy meter
+	requireError := client.require( ctx, CapabilityEnergyMeter )
+	if ( requireError != nil ) {
+		return nil, requireError
+	}
+
+	// Send the monthly statistics command
+	queryResponse, queryError := client.SendQuery( ctx, "emeter", "get_monthstat", map[string]int {
+		"year": year,
+	} )
+	if ( queryError != nil ) {
+		return nil, queryError
+	}
+
+	// Convert each month
+	monthlyUsages := make( []MonthlyUsage, len( queryResponse.EnergyMeter.Monthly.Months ) )
+	for index, month := range queryResponse.EnergyMeter.Monthly.Months {
+		monthlyUsages[ index ] = MonthlyUsage{
+			Year: month.Year,
+			Month: time.Month( month.Month ),
+			Total: legacyWatthours( month.LegacyTotal, month.Total ),
+		}
+	}
+
+	// Return the months
+	return monthlyUsages, nil
+
+}
+
+// Get the energy used on each of the last number of days up to & including today on the smart plug's clock, oldest first.
+// Days that span more than one month are fetched month by month, and days the smart plug has not recorded are zero.
+func ( client *Client ) GetRecentDailyUsage( ctx context.Context, days int ) ( []DailyUsage, error ) {
+
+	// Require at least one day
+	if ( days <= 0 ) {
+		return nil, fmt.Errorf( "invalid number of days %d, must be greater than 0", days )
+	}
+
+	// Fail if the smart plug has no energy meter, before fetching the time
+	requireError := client.require( ctx, CapabilityEnergyMeter )
+	if ( requireError != nil ) {
+		return nil, requireError
+	}
+
+	// Use the smart plug's clock to decide what today is
+	now, timeError := client.GetTime( ctx )
+	if ( timeError != nil ) {
+		return nil, timeError
+	}
+
+	// Start with every day in the range as zero, using noon to avoid daylight saving time changes skipping a day
+	today := time.Date( now.Year(), now.Month(), now.Day(), 12, 0, 0, 0, time.UTC )
+	recentUsages := make( []DailyUsage, days )
+	dayIndexes := make( map[DailyUsage]int, days )
+	for index := range recentUsages {
+		date := today.AddDate( 0, 0, index - days + 1 )
+		recentUsages[ index ] = DailyUsage{ Year: date.Year(), Month: date.Month(), Day: date.Day() }
+		dayIndexes[ recentUsages[ index ] ] = index
+	}
+
+	// Fetch each month in the range once, filling in the days that were recorded
+	first := recentUsages[ 0 ]
+	for month := time.Date( first.Year, first.Month, 1, 12, 0, 0, 0, time.UTC ); !month.After( today ); month = month.AddDate( 0, 1, 0 ) {
+		monthUsages, monthError := client.GetDailyUsage( ctx, month.Year(), month.Month() )
+		if ( monthError != nil ) {
+			return nil, monthError
+		}
+
+		for _, dayUsage := range monthUsages {
+			index, isInRange := dayIndexes[ DailyUsage{ Year: dayUsage.Year, Month: dayUsage.Month, Day: dayUsage.Day } ]
+			if ( isInRange ) {
+				recentUsages[ index ].Total = dayUsage.Total
+			}
+		}
+	}
+
+	// Return the days
+	return recentUsages, nil
+
+}
+
+// Erases all of the recorded daily & monthly energy usage, which cannot be undone
+func ( client *Client ) EraseEnergyUsage( ctx context.Context ) ( error ) {
+
+	// Fail if the smart plug has no energy meter
+	requireError := client.require( ctx, CapabilityEnergyMeter )
+	if ( requireError != nil ) {
+		return requireError
+	}
+
+	// Send the erase statistics command
+	_, queryError := client.SendQuery( ctx, "emeter", "erase_emeter_stat", nil )
+	if ( queryError != nil ) {
+		return queryError
+	}
+
+	// Return no error
+	return nil
+
+}
+
+// Returns the value in base units from older firmware if it is present, otherwise the value in thousandths from newer firmware
+func legacyOrScaled( legacyValue *float64, scaledValue int, scale float64 ) ( float64 ) {
+	if ( legacyValue != nil ) {
+		return *legacyValue
+	}
+
+	return float64( scaledValue ) / scale
+}
+
+// Returns the energy in watthours, converting from kilowatt-hours if older firmware reported it
+func legacyWatthours( legacyKilowattHours *float64, watthours int ) ( int ) {
+	if ( legacyKilowattHours != nil ) {
+		return int( math.Round( *legacyKilowattHours * 1000.0 ) )
+	}
+
+	return watthours
+}
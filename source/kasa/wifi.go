@@ -0,0 +1,101 @@
+package kasa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// The IPv4 address of a smart plug that has not joined a wireless network yet, on the network it creates itself
+var AccessPointAddress = net.IPv4( 192, 168, 0, 1 )
+
+// The type of security a wireless network uses
+type KeyType int
+
+const (
+	KeyTypeNone KeyType = 0
+	KeyTypeWEP KeyType = 1
+	KeyTypeWPA KeyType = 2
+	KeyTypeWPA2 KeyType = 3
+)
+
+// Returns the name of the security type
+func ( keyType KeyType ) String() ( string ) {
+	switch ( keyType ) {
+		case KeyTypeNone:
+			return "none"
+		case KeyTypeWEP:
+			return "wep"
+		case KeyTypeWPA:
+			return "wpa"
+		case KeyTypeWPA2:
+			return "wpa2"
+	}
+
+	return fmt.Sprintf( "unknown (%d)", int( keyType ) )
+}
+
+// Parses the name of a security type, the reverse of String
+func ParseKeyType( name string ) ( KeyType, error ) {
+	for _, keyType := range []KeyType{ KeyTypeNone, KeyTypeWEP, KeyTypeWPA, KeyTypeWPA2 } {
+		if ( strings.EqualFold( keyType.String(), name ) ) {
+			return keyType, nil
+		}
+	}
+
+	return 0, fmt.Errorf( "unknown wireless security type '%s'", name )
+}
+
+// Structure for holding a wireless network that the smart plug can see
+type AccessPoint struct {
+	SSID string `json:"ssid"`
+	KeyType KeyType `json:"key_type"`
+}
+
+// Get the wireless networks the smart plug can see, optionally scanning again rather than using the last results
+func ( client *Client ) ScanWiFi( ctx context.Context, refresh bool ) ( []AccessPoint, error ) {
+
+	// Send the scan command
+	var result struct {
+		AccessPoints []AccessPoint `json:"ap_list"`
+	}
+	callError := client.call( ctx, "netif", "get_scaninfo", map[string]int {
+		"refresh": boolToInt( refresh ),
+	}, &result )
+	if ( callError != nil ) {
+		return nil, callError
+	}
+
+	// Return the wireless networks
+	return result.AccessPoints, nil
+
+}
+
+// Joins the smart plug to a wireless network, after which it leaves its own network & is only reachable on the new one.
+// The smart plug often drops the connection or goes quiet after receiving the command but before answering, so that is not treated as a failure.
+func ( client *Client ) JoinWiFi( ctx context.Context, ssid string, password string, keyType KeyType ) ( error ) {
+
+	// Require a network name, & a password unless the network is open
+	if ( ssid == "" ) {
+		return errors.New( "wireless network name cannot be empty" )
+	}
+	if ( keyType != KeyTypeNone && password == "" ) {
+		return fmt.Errorf( "wireless network '%s' requires a password", ssid )
+	}
+
+	// Send the join command
+	callError := client.call( ctx, "netif", "set_stainfo", map[string]any {
+		"ssid": ssid,
+		"password": password,
+		"key_type": int( keyType ),
+	}, nil )
+	if ( callError != nil && !errors.Is( callError, ErrNoReply ) ) {
+		return callError
+	}
+
+	// Return no error
+	return nil
+
+}
@@ -0,0 +1,131 @@
+package kasa
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Starts a fake smart plug that reads one query & drops the connection without replying, like when joining a wireless network
+func listenAndDrop( t *testing.T ) ( *net.TCPAddr ) {
+	listener, listenError := net.Listen( "tcp4", "127.0.0.1:0" )
+	if ( listenError != nil ) {
+		t.Fatal( listenError )
+	}
+	t.Cleanup( func() { listener.Close() } )
+
+	go func() {
+		for {
+			connection, acceptError := listener.Accept()
+			if ( acceptError != nil ) {
+				return
+			}
+
+			NewConn( connection, DefaultInitialKey ).ReadFrame( nil )
+			connection.Close()
+		}
+	}()
+
+	return listener.Addr().( *net.TCPAddr )
+}
+
+// Starts a fake smart plug that reads one query & then stays silent without closing the connection, like when it leaves for another network
+func listenAndIgnore( t *testing.T ) ( *net.TCPAddr ) {
+	listener, listenError := net.Listen( "tcp4", "127.0.0.1:0" )
+	if ( listenError != nil ) {
+		t.Fatal( listenError )
+	}
+
+	// Close the connections once the test finishes, rather than when the query is read
+	var connections []net.Conn
+	var connectionsMutex sync.Mutex
+	t.Cleanup( func() {
+		listener.Close()
+
+		connectionsMutex.Lock()
+		defer connectionsMutex.Unlock()
+		for _, connection := range connections {
+			connection.Close()
+		}
+	} )
+
+	go func() {
+		for {
+			connection, acceptError := listener.Accept()
+			if ( acceptError != nil ) {
+				return
+			}
+
+			connectionsMutex.Lock()
+			connections = append( connections, connection )
+			connectionsMutex.Unlock()
+
+			NewConn( connection, DefaultInitialKey ).ReadFrame( nil )
+		}
+	}()
+
+	return listener.Addr().( *net.TCPAddr )
+}
+
+// Dropping the connection after the join command was sent must not be a failure
+func TestJoinWiFiToleratesDropAfterSending( t *testing.T ) {
+	address := listenAndDrop( t )
+	client := NewClient( address.IP, address.Port )
+	defer client.Close()
+
+	joinError := client.JoinWiFi( context.Background(), "Home Network", "password", KeyTypeWPA2 )
+	if ( joinError != nil ) {
+		t.Errorf( "expected no error, got %v", joinError )
+	}
+}
+
+// Going quiet after the join command was sent must not be a failure, whether the timeout of the client or the context passes first
+func TestJoinWiFiToleratesSilenceAfterSending( t *testing.T ) {
+	address := listenAndIgnore( t )
+
+	// The timeout of the client passes
+	client := NewClient( address.IP, address.Port )
+	client.Timeout = 100 * time.Millisecond
+	defer client.Close()
+
+	joinError := client.JoinWiFi( context.Background(), "Home Network", "password", KeyTypeWPA2 )
+	if ( joinError != nil ) {
+		t.Errorf( "expected no error after the client timeout, got %v", joinError )
+	}
+
+	// The deadline of the context passes
+	contextClient := NewClient( address.IP, address.Port )
+	contextClient.Timeout = 0
+	defer contextClient.Close()
+
+	ctx, cancel := context.WithTimeout( context.Background(), 100 * time.Millisecond )
+	defer cancel()
+	joinError = contextClient.JoinWiFi( ctx, "Home Network", "password", KeyTypeWPA2 )
+	if ( joinError != nil ) {
+		t.Errorf( "expected no error after the context deadline, got %v", joinError )
+	}
+}
+
+// Failing to connect must be a failure, as the join command was never sent
+func TestJoinWiFiFailsWhenNotConnected( t *testing.T ) {
+
+	// Find a port with nothing listening on it
+	listener, listenError := net.Listen( "tcp4", "127.0.0.1:0" )
+	if ( listenError != nil ) {
+		t.Fatal( listenError )
+	}
+	address := listener.Addr().( *net.TCPAddr )
+	listener.Close()
+
+	client := NewClient( address.IP, address.Port )
+	client.Retry.MaxAttempts = 1
+	defer client.Close()
+
+	joinError := client.JoinWiFi( context.Background(), "Home Network", "password", KeyTypeWPA2 )
+	if ( joinError == nil || errors.Is( joinError, ErrNoReply ) ) {
+		t.Errorf( "expected a connection error, got %v", joinError )
+	}
+}
@@ -1,11 +1,19 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"net"
 	"os"
 	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // Timezone database for systems without one, such as Windows
+
+	"github.com/viral32111/kasa-smart-plug/source/kasa"
 )
 
 // Metadata
@@ -30,6 +38,9 @@ kasa-smart-plug
 	[-k/--initial-key <number (def. 171)>]
 		The starting key for XOR encryption & decryption.
 		Only change if you know what you are doing!
+	[-o/--outlet <position|alias|identifier>]
+		The outlet of a power strip (e.g., HS300 or KP303) to control, by its position starting at 1, its alias or its identifier.
+		Commands apply to the whole power strip if not given.
 
 	[--api-address <string (def. '127.0.0.1')>]
 		The IP address to listen on for the HTTP API.
@@ -110,11 +121,15 @@ kasa-smart-plug
 
 	[-f/--format <human|json (def. 'human')>]
 		The output format for commands. Use JSON for machine-readable.
+	[-y/--yes]
+		Confirms commands that cannot be undone without asking, such as erasing the energy usage history or factory resetting.
 
 	[command] [arguments...]
 		Do not give any commands to act as a daemon, useful for exporting metrics & serving requests from the JSON API.
 
 Commands:
+	discover
+		Scans the local network for smart plugs & lists them.
 	info
 		Returns information about the smart plug.
 	usage [now|total|average] [7d|30d]
@@ -123,6 +138,40 @@ Commands:
 		Turns the smart plug on or off.
 	light [on|off]
 		Turns the smart plug's light on or off.
+	time [show|sync] [IANA timezone]
+		Shows the clock of each smart plug & how far it has drifted from this computer, or sets it from this computer's clock & the given timezone (def. local).
+		Accepts a comma-separated list of IP addresses, or uses every smart plug on the local network if not given.
+	emeter [erase|gains|calibrate] [argument, ...]
+		Erases the energy usage history (requires --yes), shows or sets the voltage & current gains, or calibrates them against a reference meter.
+	schedule [list|add|edit|rm|enable|disable] [argument, ...]
+		Manages the rules for switching the smart plug on or off at times of day.
+		add <on|off> <HH:MM|sunrise[+/-minutes]|sunset[+/-minutes]> [daily|weekdays|weekends|mon,tue,...] [name]
+		edit <rule> <on|off> <time> [days] [name]
+		rm <rule|all>, enable [rule], disable [rule]
+		Rules are chosen by their identifier or their number in the list, which can be marked as #2 in case an identifier is only digits.
+	countdown [list|set|cancel] [argument, ...]
+		Manages the countdown for switching the smart plug on or off after a delay, replacing any existing countdown.
+		set <on|off> <minutes|duration (e.g., 1h30m)> [name]
+	away [list|add|edit|rm|enable|disable] [argument, ...]
+		Manages the rules for randomly switching the smart plug on & off within a window, to look like someone is home.
+		add <start> <end> [days] [name], edit <rule> <start> <end> [days] [name]
+		Times & days are the same as for the schedule command.
+	wifi [scan|join] [argument, ...]
+		Lists the wireless networks the smart plug can see, or joins it to one & waits for it to appear there.
+		Uses the smart plug's own network at 192.168.0.1 if no IP address is given, for setting up new smart plugs.
+		join <ssid> [password] [none|wep|wpa|wpa2]
+	reset [seconds (def. 1)] [backup file]
+		Factory resets the smart plug after asking (or with --yes), then waits for it to come back on its own network.
+		Saves the alias, timezone, schedule & away rules to the backup file first, if given.
+	set [alias|location|icon] [argument, ...]
+		Changes the name, position or icon of the smart plug, then checks the change was saved.
+		alias <name (up to 31 characters)>, location <latitude> <longitude>, icon <hash> [image file]
+	bulb [show|on|off|brightness|colour|temperature|preset|save] [argument, ...] [transition (e.g., 2s)]
+		Shows or changes the light of a smart bulb (e.g., KL130), fading over the transition if given.
+		brightness <percent>, colour <hue> <saturation> [brightness], temperature <kelvin> [brightness], preset <number>, save <number>
+	dimmer [show|brightness|fade-on|fade-off|gentle-on|gentle-off|double-click|long-press] [argument, ...]
+		Shows or changes the brightness, fade times & button actions of a dimmer switch (e.g., HS220).
+		brightness <percent> [transition (e.g., 2s)], fade-on <duration>, double-click <none|instant|gentle|preset> [preset number]
 
 Kasa Smart Plug v2.0.1, by viral32111 (https://viral32111.com).
 https://github.com/viral32111/kasa-smart-plug
@@ -139,9 +188,26 @@ kasa -a 192.168.0.5 -f json uptime
 kasa --address 192.168.0.5 --port 9999 usage
 kasa -a 192.168.0.5 -p 9999 power on
 kasa -a 192.168.0.5 power off
+kasa -a 192.168.0.7 -o 2 power on
+kasa -a 192.168.0.8 bulb temperature 2700 50 2s
+kasa -a 192.168.0.9 dimmer brightness 30 1s
+kasa -a 192.168.0.5,192.168.0.6 time sync Europe/London
 kasa --address 192.168.0.5 metrics
 */
 
+// The capability each command needs, for commands that do not work on every model
+var commandCapabilities = map[string]kasa.Capability {
+	"usage": kasa.CapabilityEnergyMeter,
+	"emeter": kasa.CapabilityEnergyMeter,
+	"power": kasa.CapabilityRelay,
+	"light": kasa.CapabilityRelay,
+	"schedule": kasa.CapabilityTimer,
+	"countdown": kasa.CapabilityTimer,
+	"away": kasa.CapabilityTimer,
+	"bulb": kasa.CapabilityLight,
+	"dimmer": kasa.CapabilityDimmer,
+}
+
 // Entry-point
 func main() {
 
@@ -154,9 +220,13 @@ func main() {
 	flagMetricsPort := 5000
 	flagMetricsPath := "/metrics"
 	flagMetricsInterval := 15 // Default Prometheus scrape interval
+	flagDiscoveryWindow := 3
+	flagTimeout := 5
+	flagYes := false
+	flagOutlet := ""
 
 	// Setup the command-line flags
-	flag.StringVar( &flagAddress, "address", flagAddress, "The IPv4 address of the smart plug, e.g. 192.168.0.5." )
+	flag.StringVar( &flagAddress, "address", flagAddress, "The IPv4 address of the smart plug, e.g. 192.168.0.5. The time command accepts a comma-separated list." )
 	flag.IntVar( &flagPort, "port", flagPort, "The port number for the smart plug API." )
 	flag.IntVar( &flagInitialKey, "initial-key", flagInitialKey, "The initial value for the XOR encryption." )
 	flag.StringVar( &flagFormat, "format", flagFormat, "The output format, either human-readable (human) or JSON (json)." )
@@ -164,15 +234,19 @@ func main() {
 	flag.IntVar( &flagMetricsPort, "metrics-port", flagMetricsPort, "The port number to listen on for the HTTP metrics server." )
 	flag.StringVar( &flagMetricsPath, "metrics-path", flagMetricsPath, "The path to the metrics page." )
 	flag.IntVar( &flagMetricsInterval, "metrics-interval", flagMetricsInterval, "The time in seconds to wait between collecting metrics." )
+	flag.IntVar( &flagTimeout, "timeout", flagTimeout, "The time in seconds to wait for the smart plug to connect & answer each query." )
+	flag.StringVar( &flagOutlet, "outlet", flagOutlet, "The outlet of a power strip to control, by its position starting at 1, its alias or its identifier." )
+	flag.BoolVar( &flagYes, "yes", flagYes, "Confirm commands that cannot be undone, such as erasing the energy usage history or factory resetting, without asking." )
+	flag.IntVar( &flagDiscoveryWindow, "discovery-window", flagDiscoveryWindow, "The time in seconds to listen for smart plugs when scanning the local network." )
 
 	// Set a custom help message
 	flag.Usage = func() {
 		fmt.Printf( "%s, v%s, by %s (%s).\n", PROJECT_NAME, PROJECT_VERSION, AUTHOR_NAME, AUTHOR_WEBSITE )
-		fmt.Printf( "\nUsage: %s [-h/-help] [-address <IPv4 address>] [-port <number>] [-initial-key <number>] [-format <string>] [-metrics-address <IPv4 address>] [-metrics-port <number>] [-metrics-path <string>] [-metrics-interval <seconds>] [command] [argument, ...]\n", os.Args[ 0 ] )
+		fmt.Printf( "\nUsage: %s [-h/-help] [-address <IPv4 address>] [-port <number>] [-initial-key <number>] [-outlet <position|alias>] [-timeout <seconds>] [-format <string>] [-metrics-address <IPv4 address>] [-metrics-port <number>] [-metrics-path <string>] [-metrics-interval <seconds>] [-discovery-window <seconds>] [-yes] [command] [argument, ...]\n", os.Args[ 0 ] )
 
 		flag.PrintDefaults()
 
-		fmt.Printf( "\nCommands: info, usage [now|total|average] [7d|30d], power [on|off], light [on|off], metrics\n" )
+		fmt.Printf( "\nCommands: discover, info, usage [now|total|average] [7d|30d], power [on|off], light [on|off], time [show|sync] [IANA timezone], emeter [erase|gains|calibrate] [argument, ...], schedule [list|add|edit|rm|enable|disable] [argument, ...], countdown [list|set|cancel] [argument, ...], away [list|add|edit|rm|enable|disable] [argument, ...], wifi [scan|join] [argument, ...], reset [seconds] [backup file], set [alias|location|icon] [argument, ...], bulb [show|on|off|brightness|colour|temperature|preset|save] [argument, ...] [transition], dimmer [show|brightness|fade-on|fade-off|gentle-on|gentle-off|double-click|long-press] [argument, ...], metrics\n" )
 
 		os.Exit( 1 ) // By default it exits with code 2
 	}
@@ -193,17 +267,6 @@ func main() {
 		}
 	}
 
-	// Ensure an IP address is provided
-	if ( flagAddress == "" ) {
-		exitWithErrorMessage( "The IPv4 address of the smart plug must be set using the -address flag, use -help for more information." )
-	}
-
-	// Require a valid IPv4 address for the smart plug
-	plugAddress := net.ParseIP( flagAddress )
-	if ( plugAddress == nil || plugAddress.To4() == nil ) {
-		exitWithErrorMessage( "Invalid IPv4 address for smart plug." )
-	}
-
 	// Require a valid port number for the smart plug API
 	if ( flagPort <= 0 || flagPort >= 65536 ) {
 		exitWithErrorMessage( "Invalid port number for smart plug API, must be between 1 and 65535." )
@@ -237,25 +300,131 @@ func main() {
 		exitWithErrorMessage( "Invalid interval to wait between collecting metrics, must be greater than 0." )
 	}
 
-	// Create the smart plug structure
-	var smartPlug KasaSmartPlug
+	// Require a valid timeout for the smart plug
+	if ( flagTimeout <= 0 ) {
+		exitWithErrorMessage( "Invalid time to wait for the smart plug, must be greater than 0." )
+	}
+
+	// Require a valid listen window for discovery
+	if ( flagDiscoveryWindow <= 0 ) {
+		exitWithErrorMessage( "Invalid time to listen for smart plugs, must be greater than 0." )
+	}
+
+	// Setup the options for scanning the local network
+	ctx := context.Background()
+	discovery := kasa.NewDiscovery()
+	discovery.Port = flagPort
+	discovery.InitialKey = flagInitialKey
+	discovery.Window = time.Duration( flagDiscoveryWindow ) * time.Second
+
+	// Is this execution to scan the local network?
+	if ( commandName == "discover" ) {
+		runDiscoverCommand( ctx, discovery, commandArguments, flagFormat )
+		return
+	}
+
+	// Require a valid IPv4 address for each smart plug, as some commands accept a comma-separated list
+	plugAddresses := []net.IP{}
+	if ( flagAddress != "" ) {
+		for _, address := range strings.Split( flagAddress, "," ) {
+			plugAddress := net.ParseIP( strings.TrimSpace( address ) )
+			if ( plugAddress == nil || plugAddress.To4() == nil ) {
+				exitWithErrorMessage( fmt.Sprintf( "Invalid IPv4 address '%s' for smart plug.", address ) )
+			}
+
+			plugAddresses = append( plugAddresses, plugAddress )
+		}
+	}
+
+	// Creates a client for a smart plug using the options from the flags
+	newClient := func( plugAddress net.IP ) ( *kasa.Client ) {
+		client := kasa.NewClient( plugAddress, flagPort )
+		client.InitialKey = flagInitialKey
+		client.DialTimeout = time.Duration( flagTimeout ) * time.Second
+		client.Timeout = time.Duration( flagTimeout ) * time.Second
+
+		return client
+	}
+
+	// Is this execution to manage the clock of one or many smart plugs?
+	if ( commandName == "time" ) {
+
+		// Use every smart plug on the local network if no IP addresses are provided
+		if ( len( plugAddresses ) == 0 ) {
+			plugAddresses = discoverSmartPlugs( ctx, discovery )
+		}
+
+		runTimeCommand( ctx, plugAddresses, newClient, commandArguments, flagFormat )
+		return
+
+	}
+
+	// Use the address a smart plug has on its own network when joining it to a wireless network, as it cannot be discovered yet
+	if ( commandName == "wifi" && len( plugAddresses ) == 0 ) {
+		plugAddresses = []net.IP{ kasa.AccessPointAddress }
+	}
+
+	// Require a single smart plug for all other commands
+	if ( len( plugAddresses ) > 1 ) {
+		exitWithErrorMessage( fmt.Sprintf( "The '%s' command only accepts a single smart plug IPv4 address.", commandName ) )
+	}
+
+	// Scan the local network for a smart plug if an IP address is not provided
+	var plugAddress net.IP
+	if ( len( plugAddresses ) == 0 ) {
+		plugAddress = discoverSmartPlug( ctx, discovery )
+	} else {
+		plugAddress = plugAddresses[ 0 ]
+	}
+
+	// Create the client for the smart plug
+	client := newClient( plugAddress )
 
 	// Connect to the smart plug
-	connectError := smartPlug.Connect( plugAddress, flagPort, 5000 )
+	connectError := client.Connect( ctx )
 	if ( connectError != nil ) {
 		exitWithErrorMessage( connectError.Error() )
 	}
 
 	// Disconnect from the smart plug once we're done
-	defer smartPlug.Disconnect()
+	defer client.Close()
+
+	// Address every query to a single outlet of a power strip, if one is given
+	if ( flagOutlet != "" ) {
+
+		// Fetch the outlets
+		outlets, outletsError := client.GetOutlets( ctx )
+		if ( outletsError != nil ) {
+			exitWithErrorMessage( outletsError.Error() )
+		}
 
-	// Set the initial encryption & decryption key
-	smartPlug.InitialKey = flagInitialKey
+		// Require a power strip
+		if ( len( outlets ) == 0 ) {
+			exitWithErrorMessage( "The smart plug is not a power strip, so it has no outlets." )
+		}
+
+		// Find the outlet by its position, alias or identifier
+		outlet, findError := kasa.FindOutlet( outlets, flagOutlet )
+		if ( findError != nil ) {
+			exitWithErrorMessage( fmt.Sprintf( "Unrecognised outlet, %s.", findError ) )
+		}
+
+		client = client.Outlet( outlet.Identifier )
 
-	// Update all properties with latest data
-	updatePropertiesError := smartPlug.UpdateProperties()
-	if ( updatePropertiesError != nil ) {
-		exitWithErrorMessage( updatePropertiesError.Error() )
+	}
+
+	// Check the smart plug can do what the command needs, as the error from the smart plug itself is unclear
+	requiredCapability, isCapabilityRequired := commandCapabilities[ commandName ]
+	if ( isCapabilityRequired ) {
+		smartPlug, infoError := client.GetSystemInformation( ctx )
+		if ( infoError != nil ) {
+			exitWithErrorMessage( infoError.Error() )
+		}
+
+		requireError := smartPlug.Capabilities.Require( requiredCapability, smartPlug.DeviceModel )
+		if ( requireError != nil ) {
+			exitWithErrorMessage( fmt.Sprintf( "Cannot use the '%s' command, %s.", commandName, requireError ) )
+		}
 	}
 
 	// Is this execution for device information?
@@ -266,13 +435,36 @@ func main() {
 			exitWithErrorMessage( "Information command does not require any arguments." )
 		}
 
+		// Fetch all properties with the latest data
+		smartPlug, propertiesError := client.GetProperties( ctx )
+		if ( propertiesError != nil ) {
+			exitWithErrorMessage( propertiesError.Error() )
+		}
+
 		// TODO: Display device information
 		fmt.Printf( "Alias: '%s'.\n", smartPlug.Alias )
 		fmt.Printf( "Icon: '%s'.\n", smartPlug.Icon )
 		fmt.Printf( "Status: '%s'.\n", smartPlug.Status )
 		fmt.Printf( "Uptime: '%d'.\n", smartPlug.Uptime )
-		fmt.Printf( "Power State: '%t'.\n", smartPlug.PowerState )
-		fmt.Printf( "Light State: '%t'.\n", smartPlug.LightState )
+		if ( smartPlug.Capabilities.Has( kasa.CapabilityClock ) ) {
+			fmt.Printf( "Time: '%s'.\n", smartPlug.Time.Format( time.RFC1123Z ) )
+		}
+		if ( smartPlug.Capabilities.Has( kasa.CapabilityRelay ) ) {
+			fmt.Printf( "Power State: '%t'.\n", smartPlug.PowerState )
+			fmt.Printf( "Light State: '%t'.\n", smartPlug.LightState )
+		} else if ( smartPlug.Capabilities.Has( kasa.CapabilityLight ) ) {
+
+			// Smart bulbs have no relay, so use the light state instead
+			smartBulb, bulbError := client.GetBulbInformation( ctx )
+			if ( bulbError != nil ) {
+				exitWithErrorMessage( bulbError.Error() )
+			}
+			fmt.Printf( "Power State: '%t'.\n", smartBulb.Light.PowerState )
+			fmt.Printf( "Light: '%s'.\n", formatLight( smartBulb.Light.Hue, smartBulb.Light.Saturation, smartBulb.Light.ColourTemperature, smartBulb.Light.Brightness ) )
+
+		}
+		fmt.Printf( "Active Mode: '%s'.\n", formatActiveMode( smartPlug.Action.Name ) )
+		fmt.Printf( "Countdown Remaining: '%s'.\n", smartPlug.Action.CountdownRemaining )
 		fmt.Printf( "Device Name: '%s'.\n", smartPlug.DeviceName )
 		fmt.Printf( "Device Model: '%s'.\n", smartPlug.DeviceModel )
 		fmt.Printf( "Device Identifier: '%s'.\n", smartPlug.DeviceIdentifier )
@@ -281,15 +473,23 @@ func main() {
 		fmt.Printf( "Firmware Version: '%s'.\n", smartPlug.FirmwareVersion )
 		fmt.Printf( "OEM Identifier: '%s'.\n", smartPlug.OEMIdentifier )
 		fmt.Printf( "MAC Address: '%s'.\n", smartPlug.MACAddress )
-		fmt.Printf( "Total Energy: '%d'.\n", smartPlug.Energy.Total )
-		fmt.Printf( "Wattage: '%f'.\n", smartPlug.Energy.Wattage )
-		fmt.Printf( "Voltage: '%f'.\n", smartPlug.Energy.Voltage )
-		fmt.Printf( "Amperage: '%f'.\n", smartPlug.Energy.Amperage )
+		fmt.Printf( "Capabilities: '%s'.\n", formatCapabilities( smartPlug.Capabilities ) )
+		if ( smartPlug.Capabilities.Has( kasa.CapabilityEnergyMeter ) ) {
+			fmt.Printf( "Total Energy: '%d'.\n", smartPlug.Energy.Total )
+			fmt.Printf( "Wattage: '%f'.\n", smartPlug.Energy.Wattage )
+			fmt.Printf( "Voltage: '%f'.\n", smartPlug.Energy.Voltage )
+			fmt.Printf( "Amperage: '%f'.\n", smartPlug.Energy.Amperage )
+		}
 		fmt.Printf( "Signal Strength: '%d'.\n", smartPlug.SignalStrength )
 		fmt.Printf( "Source: '%s'.\n", smartPlug.Source )
 		fmt.Printf( "Type: '%s'.\n", smartPlug.Type )
 		fmt.Printf( "NTC State: '%d'.\n", smartPlug.NTCState )
 
+		// Display each outlet, on power strips
+		for index, outlet := range smartPlug.Outlets {
+			fmt.Printf( "Outlet %d: '%s' (%s), Power State: '%t', Uptime: '%d', Wattage: '%f', Total Energy: '%d'.\n", index + 1, outlet.Alias, outlet.Identifier, outlet.PowerState, outlet.Uptime, outlet.Energy.Wattage, outlet.Energy.Total )
+		}
+
 	// Is this execution for energy usage?
 	} else if ( commandName == "usage" ) {
 
@@ -319,7 +519,7 @@ func main() {
 			}
 
 			// Parse the energy usage period
-			parsedPeriod, parseError := strconv.ParseInt( commandArguments[ 1 ], 10, 32 )
+			parsedPeriod, parseError := strconv.ParseInt( strings.TrimSuffix( commandArguments[ 1 ], "d" ), 10, 32 )
 			if ( parseError != nil ) {
 				fmt.Fprintf( os.Stderr, "Error while parsing energy usage period: '%s'\n", parseError )
 				os.Exit( 1 )
@@ -327,7 +527,7 @@ func main() {
 
 			// Require a valid energy usage period
 			if ( parsedPeriod != 7 && parsedPeriod != 30 ) {
-				exitWithErrorMessage( "Invalid energy usage period, must be either 7 or 30." )
+				exitWithErrorMessage( "Invalid energy usage period, must be either 7d or 30d." )
 			}
 
 			// Set the energy usage period from a 64-bit to a regular integer
@@ -340,10 +540,8 @@ func main() {
 			exitWithErrorMessage( "Energy usage command does not accept more than 2 arguments." )
 		}
 
-		// TODO: Display energy usage
-
-		// Debugging
-		fmt.Println( usageType, usagePeriod )
+		// Display the energy usage
+		runUsageCommand( ctx, client, usageType, usagePeriod, flagFormat )
 
 	// Is this execution to control the power relay?
 	} else if ( commandName == "power" ) {
@@ -365,10 +563,11 @@ func main() {
 			exitWithErrorMessage( "Invalid power state, must be either 'on' or 'off'." )
 		}
 
-		// TODO: Set relay state
-
-		// Debugging
-		fmt.Println( powerState )
+		// Set the relay state
+		setError := client.SetPowerState( ctx, powerState )
+		if ( setError != nil ) {
+			exitWithErrorMessage( setError.Error() )
+		}
 
 	// Is this execution to control the light?
 	} else if ( commandName == "light" ) {
@@ -390,10 +589,47 @@ func main() {
 			exitWithErrorMessage( "Invalid light state, must be either 'on' or 'off'." )
 		}
 
-		// TODO: Set light state
+		// Set the light state
+		setError := client.SetLightState( ctx, lightState )
+		if ( setError != nil ) {
+			exitWithErrorMessage( setError.Error() )
+		}
+
+	// Is this execution to manage the energy meter?
+	} else if ( commandName == "emeter" ) {
+		runEmeterCommand( ctx, client, commandArguments, flagFormat, flagYes )
+
+	// Is this execution to manage the schedule?
+	} else if ( commandName == "schedule" ) {
+		runScheduleCommand( ctx, client, commandArguments, flagFormat, flagYes )
+
+	// Is this execution to manage the countdown?
+	} else if ( commandName == "countdown" ) {
+		runCountdownCommand( ctx, client, commandArguments, flagFormat )
+
+	// Is this execution to manage away mode?
+	} else if ( commandName == "away" ) {
+		runAwayCommand( ctx, client, commandArguments, flagFormat, flagYes )
 
-		// Debugging
-		fmt.Println( lightState )
+	// Is this execution to manage the wireless network?
+	} else if ( commandName == "wifi" ) {
+		runWiFiCommand( ctx, client, discovery, commandArguments, flagFormat )
+
+	// Is this execution to factory reset the smart plug?
+	} else if ( commandName == "reset" ) {
+		runResetCommand( ctx, client, commandArguments, flagFormat, flagYes )
+
+	// Is this execution to change the alias, location or icon?
+	} else if ( commandName == "set" ) {
+		runSetCommand( ctx, client, commandArguments, flagFormat )
+
+	// Is this execution to control the light of a smart bulb?
+	} else if ( commandName == "bulb" ) {
+		runBulbCommand( ctx, client, commandArguments, flagFormat )
+
+	// Is this execution to control a dimmer switch?
+	} else if ( commandName == "dimmer" ) {
+		runDimmerCommand( ctx, client, commandArguments, flagFormat )
 
 	// Is this execution to serve metrics?
 	} else if ( commandName == "metrics" ) {
@@ -417,3 +653,44 @@ func exitWithErrorMessage( message string ) {
 	fmt.Fprintln( os.Stderr, message )
 	os.Exit( 1 )
 }
+
+// Formats the capabilities of a smart plug as a comma-separated list
+func formatCapabilities( capabilities kasa.Capabilities ) ( string ) {
+	names := []string{}
+	for _, capability := range capabilities.List() {
+		names = append( names, string( capability ) )
+	}
+
+	return strings.Join( names, ", " )
+}
+
+// Displays a value as indented JSON on the standard output stream, for the JSON output format
+func printJSON( value any ) {
+	jsonBytes, encodeError := json.MarshalIndent( value, "", "\t" )
+	if ( encodeError != nil ) {
+		exitWithErrorMessage( encodeError.Error() )
+	}
+
+	fmt.Println( string( jsonBytes ) )
+}
+
+// Buffered reader for the standard input stream, shared so answers typed ahead are not lost between questions
+var standardInput = bufio.NewReader( os.Stdin )
+
+// Asks a question on the standard error stream & returns the answer from the standard input stream
+func askForInput( question string ) ( string ) {
+	fmt.Fprint( os.Stderr, question )
+
+	answer, readError := standardInput.ReadString( '\n' )
+	if ( readError != nil && answer == "" ) {
+		exitWithErrorMessage( "\nNo answer given." )
+	}
+
+	return strings.TrimSpace( answer )
+}
+
+// Asks a yes or no question, defaulting to no
+func askForConfirmation( question string ) ( bool ) {
+	answer := strings.ToLower( askForInput( question + " [y/N] " ) )
+	return answer == "y" || answer == "yes"
+}
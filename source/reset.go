@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/viral32111/kasa-smart-plug/source/kasa"
+)
+
+// How long to wait for a smart plug to come back on its own network after a factory reset
+const resetTimeout = 3 * time.Minute
+
+// Structure for the settings saved before a factory reset, so they can be put back by hand afterwards
+type resetBackup struct {
+	CreatedAt time.Time `json:"created_at"`
+	MACAddress string `json:"mac"`
+	Model string `json:"model"`
+	Alias string `json:"alias"`
+	TimezoneIndex int `json:"timezone_index"`
+	ScheduleEnabled bool `json:"schedule_enabled"`
+	ScheduleRules []kasa.ScheduleRule `json:"schedule_rules"`
+	AwayEnabled bool `json:"away_enabled"`
+	AwayRules []kasa.AwayRule `json:"away_rules"`
+}
+
+// Factory resets a smart plug after asking, optionally saving its settings first, then waits for it to come back on its own network
+func runResetCommand( ctx context.Context, client *kasa.Client, commandArguments []string, outputFormat string, isConfirmed bool ) {
+
+	// Parse the optional delay & backup file, in any order
+	delay := 1
+	backupPath := ""
+	for _, argument := range commandArguments {
+		parsedDelay, parseError := strconv.Atoi( argument )
+		if ( parseError == nil && parsedDelay >= 1 ) {
+			delay = parsedDelay
+		} else if ( parseError == nil ) {
+			exitWithErrorMessage( "Invalid delay for factory reset, must be at least 1 second." )
+		} else if ( backupPath == "" ) {
+			backupPath = argument
+		} else {
+			exitWithErrorMessage( "Reset command accepts at most 2 arguments for the delay & backup file." )
+		}
+	}
+
+	// Reports progress, only for the human-readable output format
+	progress := func( format string, arguments ...any ) {
+		if ( outputFormat == "human" ) {
+			fmt.Printf( format + "\n", arguments... )
+		}
+	}
+
+	// Remember which smart plug this is, to recognise it afterwards
+	smartPlug, infoError := client.GetSystemInformation( ctx )
+	if ( infoError != nil ) {
+		exitWithErrorMessage( infoError.Error() )
+	}
+
+	// Save the settings first, if requested
+	if ( backupPath != "" ) {
+		backupError := saveResetBackup( ctx, client, smartPlug, backupPath )
+		if ( backupError != nil ) {
+			exitWithErrorMessage( fmt.Sprintf( "Not resetting, as the backup failed: %s", backupError ) )
+		}
+		progress( "Saved the settings to '%s'.", backupPath )
+	}
+
+	// Require explicit confirmation, as this cannot be undone
+	if ( !isConfirmed && !askForConfirmation( fmt.Sprintf( "Factory reset smart plug '%s' (%s) at %s? This erases all settings & it will leave the wireless network.", smartPlug.Alias, smartPlug.MACAddress, client.Address ) ) ) {
+		exitWithErrorMessage( "Factory reset cancelled." )
+	}
+
+	// Factory reset the smart plug
+	resetError := client.Reset( ctx, delay )
+	if ( resetError != nil ) {
+		exitWithErrorMessage( resetError.Error() )
+	}
+	client.Close()
+	progress( "The smart plug will factory reset in %d second(s).", delay )
+
+	// Wait for the smart plug to come back on its own network
+	progress( "Connect this computer to the smart plug's own network (usually named TP-LINK_Smart Plug_%s), waiting up to %s for it to appear at %s...", lastMACDigits( smartPlug.MACAddress ), resetTimeout, kasa.AccessPointAddress )
+	isBack := waitForAccessPointMode( ctx, client, smartPlug.MACAddress, time.Duration( delay ) * time.Second )
+
+	// Display the result as JSON if requested
+	if ( outputFormat == "json" ) {
+		printJSON( map[string]any{ "reset": true, "access_point_mode": isBack } )
+	} else if ( isBack ) {
+		progress( "The smart plug is ready to set up again, use the wifi join command to join it to a wireless network." )
+	}
+
+	// Fail if the smart plug never came back
+	if ( !isBack ) {
+		exitWithErrorMessage( fmt.Sprintf( "The smart plug did not appear at %s, check this computer is connected to its network.", kasa.AccessPointAddress ) )
+	}
+
+}
+
+// Saves the alias, timezone, schedule & away rules to a JSON file, skipping anything the smart plug does not support
+func saveResetBackup( ctx context.Context, client *kasa.Client, smartPlug kasa.SmartPlug, backupPath string ) ( error ) {
+	backup := resetBackup{
+		CreatedAt: time.Now(),
+		MACAddress: smartPlug.MACAddress,
+		Model: smartPlug.DeviceModel,
+		Alias: smartPlug.Alias,
+	}
+
+	// Fetch the timezone
+	timezoneIndex, _, timezoneError := client.GetTimezone( ctx )
+	if ( timezoneError != nil && !errors.Is( timezoneError, kasa.ErrModuleNotSupported ) ) {
+		return timezoneError
+	}
+	backup.TimezoneIndex = timezoneIndex
+
+	// Fetch the schedule
+	scheduleRules, scheduleEnabled, scheduleError := client.GetScheduleRules( ctx )
+	if ( scheduleError != nil && !errors.Is( scheduleError, kasa.ErrModuleNotSupported ) ) {
+		return scheduleError
+	}
+	backup.ScheduleRules, backup.ScheduleEnabled = scheduleRules, scheduleEnabled
+
+	// Fetch away mode
+	awayRules, awayEnabled, awayError := client.GetAwayRules( ctx )
+	if ( awayError != nil && !errors.Is( awayError, kasa.ErrModuleNotSupported ) ) {
+		return awayError
+	}
+	backup.AwayRules, backup.AwayEnabled = awayRules, awayEnabled
+
+	// Write the file, readable only by this user as it describes the home
+	backupBytes, encodeError := json.MarshalIndent( backup, "", "\t" )
+	if ( encodeError != nil ) {
+		return encodeError
+	}
+
+	return os.WriteFile( backupPath, append( backupBytes, '\n' ), 0600 )
+}
+
+// Polls the address a smart plug has on its own network until the smart plug with the given MAC address answers, or the time runs out
+func waitForAccessPointMode( ctx context.Context, client *kasa.Client, macAddress string, delay time.Duration ) ( bool ) {
+
+	// Wait for the reset to start, so the smart plug is not found on its old network if the addresses happen to be the same
+	time.Sleep( delay )
+
+	deadline := time.Now().Add( resetTimeout )
+	for ( time.Now().Before( deadline ) ) {
+
+		// Try to connect with the same options as before
+		accessPointClient := kasa.NewClient( kasa.AccessPointAddress, client.Port )
+		accessPointClient.InitialKey = client.InitialKey
+		accessPointClient.DialTimeout = client.DialTimeout
+		accessPointClient.Timeout = client.Timeout
+
+		// Check it is the same smart plug
+		smartPlug, infoError := accessPointClient.GetSystemInformation( ctx )
+		accessPointClient.Close()
+		if ( infoError == nil && strings.EqualFold( smartPlug.MACAddress, macAddress ) ) {
+			return true
+		}
+
+		// Wait before trying again
+		time.Sleep( 2 * time.Second )
+
+	}
+
+	return false
+
+}
+
+// Returns the last 4 hexadecimal digits of a MAC address, which smart plugs use in the name of their own network
+func lastMACDigits( macAddress string ) ( string ) {
+	digits := strings.ToUpper( strings.ReplaceAll( macAddress, ":", "" ) )
+	if ( len( digits ) < 4 ) {
+		return "XXXX"
+	}
+
+	return digits[ len( digits ) - 4 : ]
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/viral32111/kasa-smart-plug/source/kasa"
+)
+
+// The short names of the days of the week, starting from Sunday like the smart plug
+var weekdayNames = [ 7 ]string{ "sun", "mon", "tue", "wed", "thu", "fri", "sat" }
+
+// Structure for a schedule rule, for the JSON output format
+type scheduleRuleReport struct {
+	Identifier string `json:"id"`
+	Name string `json:"name"`
+	Enabled bool `json:"enabled"`
+	Days []string `json:"days"`
+	Time string `json:"time"`
+	Power bool `json:"power"`
+}
+
+// Structure for the schedule, for the JSON output format
+type scheduleReport struct {
+	Enabled bool `json:"enabled"`
+	Rules []scheduleRuleReport `json:"rules"`
+}
+
+// Lists, adds, edits, removes, enables or disables the schedule rules
+func runScheduleCommand( ctx context.Context, client *kasa.Client, commandArguments []string, outputFormat string, isConfirmed bool ) {
+
+	// Default to listing the rules
+	action := "list"
+	actionArguments := []string{}
+	if ( len( commandArguments ) > 0 ) {
+		action, actionArguments = commandArguments[ 0 ], commandArguments[ 1 : ]
+	}
+
+	// Is this to list the rules?
+	if ( action == "list" ) {
+
+		// Require no arguments
+		if ( len( actionArguments ) > 0 ) {
+			exitWithErrorMessage( "Schedule list command does not require any arguments." )
+		}
+
+	// Is this to add a rule?
+	} else if ( action == "add" ) {
+
+		// Require the power state & time, with optional days & name
+		if ( len( actionArguments ) < 2 || len( actionArguments ) > 4 ) {
+			exitWithErrorMessage( "Schedule add command requires arguments for the power state & time, then optionally the days & name." )
+		}
+
+		// Parse the rule, enabled by default
+		rule := kasa.ScheduleRule{ Enabled: true }
+		parseScheduleRule( &rule, actionArguments )
+
+		// Add the rule
+		identifier, addError := client.AddScheduleRule( ctx, rule )
+		if ( addError != nil ) {
+			exitWithErrorMessage( addError.Error() )
+		}
+		if ( outputFormat == "human" ) {
+			fmt.Printf( "Added schedule rule '%s'.\n", identifier )
+		}
+
+	// Is this to edit a rule?
+	} else if ( action == "edit" ) {
+
+		// Require the rule, power state & time, with optional days & name
+		if ( len( actionArguments ) < 3 || len( actionArguments ) > 5 ) {
+			exitWithErrorMessage( "Schedule edit command requires arguments for the rule, power state & time, then optionally the days & name." )
+		}
+
+		// Change the existing rule, keeping the days & name unless they are given
+		rule := findScheduleRule( ctx, client, actionArguments[ 0 ] )
+		parseScheduleRule( &rule, actionArguments[ 1 : ] )
+
+		// Replace the rule
+		editError := client.EditScheduleRule( ctx, rule )
+		if ( editError != nil ) {
+			exitWithErrorMessage( editError.Error() )
+		}
+
+	// Is this to remove a rule, or all of them?
+	} else if ( action == "rm" ) {
+
+		// Require the rule
+		if ( len( actionArguments ) != 1 ) {
+			exitWithErrorMessage( "Schedule remove command requires 1 argument for the rule, or 'all'." )
+		}
+
+		// Remove every rule, which requires confirmation
+		if ( actionArguments[ 0 ] == "all" ) {
+			if ( !isConfirmed ) {
+				exitWithErrorMessage( "Removing every schedule rule cannot be undone, use the -yes flag to confirm." )
+			}
+
+			deleteError := client.DeleteAllScheduleRules( ctx )
+			if ( deleteError != nil ) {
+				exitWithErrorMessage( deleteError.Error() )
+			}
+
+		// Otherwise, remove the one rule
+		} else {
+			rule := findScheduleRule( ctx, client, actionArguments[ 0 ] )
+
+			deleteError := client.DeleteScheduleRule( ctx, rule.Identifier )
+			if ( deleteError != nil ) {
+				exitWithErrorMessage( deleteError.Error() )
+			}
+		}
+
+	// Is this to enable or disable a rule, or the whole schedule?
+	} else if ( action == "enable" || action == "disable" ) {
+		enabled := ( action == "enable" )
+
+		// Change the whole schedule if no rule is given
+		if ( len( actionArguments ) == 0 ) {
+			setError := client.SetScheduleEnabled( ctx, enabled )
+			if ( setError != nil ) {
+				exitWithErrorMessage( setError.Error() )
+			}
+
+		// Otherwise, change the one rule
+		} else if ( len( actionArguments ) == 1 ) {
+			rule := findScheduleRule( ctx, client, actionArguments[ 0 ] )
+			rule.Enabled = enabled
+
+			editError := client.EditScheduleRule( ctx, rule )
+			if ( editError != nil ) {
+				exitWithErrorMessage( editError.Error() )
+			}
+
+		// Require at most the rule
+		} else {
+			exitWithErrorMessage( "Schedule enable & disable commands accept at most 1 argument for the rule." )
+		}
+
+	// Require a valid action
+	} else {
+		exitWithErrorMessage( "Invalid schedule action, must be either 'list', 'add', 'edit', 'rm', 'enable' or 'disable'." )
+	}
+
+	// Display the rules afterwards, so the change can be checked
+	displaySchedule( ctx, client, outputFormat )
+
+}
+
+// Parses the power state, time & optionally the days & name into a rule
+func parseScheduleRule( rule *kasa.ScheduleRule, ruleArguments []string ) {
+
+	// Parse the power state
+	if ( ruleArguments[ 0 ] == "on" ) {
+		rule.PowerState = true
+	} else if ( ruleArguments[ 0 ] == "off" ) {
+		rule.PowerState = false
+	} else {
+		exitWithErrorMessage( "Invalid power state, must be either 'on' or 'off'." )
+	}
+
+	// Parse the time
+	reference, minutes, offset, timeError := parseRuleTime( ruleArguments[ 1 ] )
+	if ( timeError != nil ) {
+		exitWithErrorMessage( timeError.Error() )
+	}
+	rule.Reference, rule.Minutes, rule.Offset = reference, minutes, offset
+
+	// Parse the days, every day by default when adding
+	if ( len( ruleArguments ) > 2 ) {
+		weekdays, weekdaysError := parseWeekdays( ruleArguments[ 2 ] )
+		if ( weekdaysError != nil ) {
+			exitWithErrorMessage( weekdaysError.Error() )
+		}
+
+		rule.Weekdays = weekdays
+	} else if ( rule.Identifier == "" ) {
+		rule.Weekdays = [ 7 ]bool{ true, true, true, true, true, true, true }
+	}
+
+	// Use the name
+	if ( len( ruleArguments ) > 3 ) {
+		rule.Name = ruleArguments[ 3 ]
+	}
+
+}
+
+// Parses a time of day (HH:MM) or sunrise/sunset with an optional offset in minutes (sunset-30), returning the reference, minutes after midnight & offset
+func parseRuleTime( value string ) ( kasa.TimeReference, int, int, error ) {
+
+	// Is this relative to sunrise or sunset?
+	for reference, name := range map[kasa.TimeReference]string{ kasa.Sunrise: "sunrise", kasa.Sunset: "sunset" } {
+		if ( !strings.HasPrefix( value, name ) ) {
+			continue
+		}
+
+		// Parse the offset, if there is one
+		offset := 0
+		if ( len( value ) > len( name ) ) {
+			parsedOffset, parseError := strconv.Atoi( value[ len( name ) : ] )
+			if ( parseError != nil || ( value[ len( name ) ] != '+' && value[ len( name ) ] != '-' ) ) {
+				return 0, 0, 0, fmt.Errorf( "Invalid offset from %s '%s', must be minutes such as %s+30 or %s-15.", name, value, name, name )
+			}
+
+			offset = parsedOffset
+		}
+
+		return reference, 0, offset, nil
+	}
+
+	// Otherwise, parse the time of day
+	var hour, minute int
+	_, scanError := fmt.Sscanf( value, "%d:%d", &hour, &minute )
+	if ( scanError != nil || hour < 0 || hour > 23 || minute < 0 || minute > 59 ) {
+		return 0, 0, 0, fmt.Errorf( "Invalid time '%s', must be either HH:MM, sunrise or sunset.", value )
+	}
+
+	return kasa.TimeOfDay, hour * 60 + minute, 0, nil
+
+}
+
+// Parses the days of the week, either daily, weekdays, weekends, or a comma-separated list of short names (mon,wed,fri)
+func parseWeekdays( value string ) ( [ 7 ]bool, error ) {
+	weekdays := [ 7 ]bool{}
+
+	// Is this a group of days?
+	if ( value == "daily" ) {
+		return [ 7 ]bool{ true, true, true, true, true, true, true }, nil
+	} else if ( value == "weekdays" ) {
+		return [ 7 ]bool{ false, true, true, true, true, true, false }, nil
+	} else if ( value == "weekends" ) {
+		return [ 7 ]bool{ true, false, false, false, false, false, true }, nil
+	}
+
+	// Otherwise, parse each day
+	for _, day := range strings.Split( strings.ToLower( value ), "," ) {
+		found := false
+		for index, name := range weekdayNames {
+			if ( strings.TrimSpace( day ) == name ) {
+				weekdays[ index ], found = true, true
+			}
+		}
+
+		if ( !found ) {
+			return weekdays, fmt.Errorf( "Invalid day '%s', must be daily, weekdays, weekends, or a comma-separated list of sun, mon, tue, wed, thu, fri & sat.", day )
+		}
+	}
+
+	return weekdays, nil
+}
+
+// Finds a schedule rule by its identifier or its number in the list (starting from 1), exiting if there is none
+func findScheduleRule( ctx context.Context, client *kasa.Client, value string ) ( kasa.ScheduleRule ) {
+
+	// Fetch the rules
+	rules, _, rulesError := client.GetScheduleRules( ctx )
+	if ( rulesError != nil ) {
+		exitWithErrorMessage( rulesError.Error() )
+	}
+
+	// Find the rule
+	identifiers := make( []string, len( rules ) )
+	for index, rule := range rules {
+		identifiers[ index ] = rule.Identifier
+	}
+	index := findRuleIndex( identifiers, value )
+	if ( index < 0 ) {
+		exitWithErrorMessage( fmt.Sprintf( "No schedule rule '%s', use the list command to see them.", value ) )
+	}
+
+	return rules[ index ]
+
+}
+
+// Returns the index of a rule by its identifier or its number in the list (starting from 1, optionally as #1), or -1 if there is none
+func findRuleIndex( identifiers []string, value string ) ( int ) {
+
+	// Always use the number in the list if it is marked as one
+	numberText, isMarkedNumber := strings.CutPrefix( value, "#" )
+	if ( isMarkedNumber ) {
+		return ruleNumberIndex( identifiers, numberText )
+	}
+
+	// Prefer the identifier, as an identifier of only digits would otherwise choose the wrong rule
+	for index, identifier := range identifiers {
+		if ( strings.EqualFold( identifier, value ) ) {
+			return index
+		}
+	}
+
+	// Otherwise, use the number in the list, as identifiers are long
+	return ruleNumberIndex( identifiers, value )
+
+}
+
+// Returns the index of a rule by its number in the list (starting from 1), or -1 if there is none
+func ruleNumberIndex( identifiers []string, value string ) ( int ) {
+	number, parseError := strconv.Atoi( value )
+	if ( parseError != nil || number < 1 || number > len( identifiers ) ) {
+		return -1
+	}
+
+	return number - 1
+}
+
+// Formats the time of a rule, the reverse of parsing it
+func formatRuleTime( reference kasa.TimeReference, minutes int, offset int ) ( string ) {
+	name := "sunrise"
+	if ( reference == kasa.TimeOfDay ) {
+		return fmt.Sprintf( "%02d:%02d", minutes / 60, minutes % 60 )
+	} else if ( reference == kasa.Sunset ) {
+		name = "sunset"
+	}
+
+	if ( offset != 0 ) {
+		return fmt.Sprintf( "%s%+d", name, offset )
+	}
+
+	return name
+}
+
+// Returns the short names of the days a rule repeats on
+func formatWeekdays( weekdays [ 7 ]bool ) ( []string ) {
+	names := []string{}
+	for index, weekday := range weekdays {
+		if ( weekday ) {
+			names = append( names, weekdayNames[ index ] )
+		}
+	}
+
+	return names
+}
+
+// Displays the schedule rules
+func displaySchedule( ctx context.Context, client *kasa.Client, outputFormat string ) {
+
+	// Fetch the rules
+	rules, enabled, rulesError := client.GetScheduleRules( ctx )
+	if ( rulesError != nil ) {
+		exitWithErrorMessage( rulesError.Error() )
+	}
+
+	// Convert each rule
+	report := scheduleReport{ Enabled: enabled, Rules: make( []scheduleRuleReport, len( rules ) ) }
+	for index, rule := range rules {
+		report.Rules[ index ] = scheduleRuleReport{
+			Identifier: rule.Identifier,
+			Name: rule.Name,
+			Enabled: rule.Enabled,
+			Days: formatWeekdays( rule.Weekdays ),
+			Time: formatRuleTime( rule.Reference, rule.Minutes, rule.Offset ),
+			Power: rule.PowerState,
+		}
+	}
+
+	// Display the rules as JSON if requested
+	if ( outputFormat == "json" ) {
+		printJSON( report )
+		return
+	}
+
+	// Display each rule on its own line
+	if ( enabled ) {
+		fmt.Printf( "Schedule is enabled with %d rule(s).\n", len( rules ) )
+	} else {
+		fmt.Printf( "Schedule is disabled with %d rule(s).\n", len( rules ) )
+	}
+	for index, rule := range report.Rules {
+		state := "disabled"
+		if ( rule.Enabled ) {
+			state = "enabled"
+		}
+		power := "off"
+		if ( rule.Power ) {
+			power = "on"
+		}
+
+		fmt.Printf( "%d\t%s\t%s\t%s at %s on %s\t'%s'\n", index + 1, rule.Identifier, state, power, rule.Time, strings.Join( rule.Days, "," ), rule.Name )
+	}
+
+}
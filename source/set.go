@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/viral32111/kasa-smart-plug/source/kasa"
+)
+
+// Sets the alias, location or icon of the smart plug, then reads it back to check it was saved
+func runSetCommand( ctx context.Context, client *kasa.Client, commandArguments []string, outputFormat string ) {
+
+	// Require a property
+	if ( len( commandArguments ) == 0 ) {
+		exitWithErrorMessage( "Set command requires an argument for the property, either 'alias', 'location' or 'icon'." )
+	}
+	property, propertyArguments := commandArguments[ 0 ], commandArguments[ 1 : ]
+
+	// Is this to rename the smart plug?
+	if ( property == "alias" ) {
+
+		// Require the alias, allowing spaces without quotes
+		if ( len( propertyArguments ) == 0 ) {
+			exitWithErrorMessage( "Set alias command requires an argument for the alias." )
+		}
+		alias := strings.Join( propertyArguments, " " )
+
+		// Set the alias
+		setError := client.SetAlias( ctx, alias )
+		if ( setError != nil ) {
+			exitWithErrorMessage( setError.Error() )
+		}
+
+		// Check it was saved
+		smartPlug := readBackProperties( ctx, client )
+		if ( smartPlug.Alias != alias ) {
+			exitWithErrorMessage( fmt.Sprintf( "The smart plug reports alias '%s' instead of '%s'.", smartPlug.Alias, alias ) )
+		}
+
+		displayProperty( outputFormat, "alias", smartPlug.Alias, fmt.Sprintf( "Alias: '%s'.", smartPlug.Alias ) )
+
+	// Is this to move the smart plug?
+	} else if ( property == "location" ) {
+
+		// Require the latitude & longitude
+		if ( len( propertyArguments ) != 2 ) {
+			exitWithErrorMessage( "Set location command requires 2 arguments for the latitude & longitude." )
+		}
+		latitude, latitudeParseError := strconv.ParseFloat( propertyArguments[ 0 ], 64 )
+		longitude, longitudeParseError := strconv.ParseFloat( propertyArguments[ 1 ], 64 )
+		if ( latitudeParseError != nil || longitudeParseError != nil ) {
+			exitWithErrorMessage( "Invalid location, the latitude & longitude must be decimal degrees (e.g., 51.5072 -0.1276)." )
+		}
+
+		// Set the location
+		setError := client.SetLocation( ctx, latitude, longitude )
+		if ( setError != nil ) {
+			exitWithErrorMessage( setError.Error() )
+		}
+
+		// Check it was saved, to the precision the smart plug stores
+		smartPlug := readBackProperties( ctx, client )
+		if ( math.Abs( smartPlug.Latitude - latitude ) > 0.0001 || math.Abs( smartPlug.Longitude - longitude ) > 0.0001 ) {
+			exitWithErrorMessage( fmt.Sprintf( "The smart plug reports location %.4f, %.4f instead of %.4f, %.4f.", smartPlug.Latitude, smartPlug.Longitude, latitude, longitude ) )
+		}
+
+		displayProperty( outputFormat, "location", map[string]float64{ "latitude": smartPlug.Latitude, "longitude": smartPlug.Longitude }, fmt.Sprintf( "Location: '%.4f, %.4f'.", smartPlug.Latitude, smartPlug.Longitude ) )
+
+	// Is this to change the icon of the smart plug?
+	} else if ( property == "icon" ) {
+
+		// Require the hash, with an optional image file
+		if ( len( propertyArguments ) < 1 || len( propertyArguments ) > 2 ) {
+			exitWithErrorMessage( "Set icon command requires an argument for the icon hash, then optionally the path to the image." )
+		}
+		iconHash := propertyArguments[ 0 ]
+
+		// Read the image
+		var image []byte
+		if ( len( propertyArguments ) > 1 ) {
+			readImage, readError := os.ReadFile( propertyArguments[ 1 ] )
+			if ( readError != nil ) {
+				exitWithErrorMessage( readError.Error() )
+			}
+
+			image = readImage
+		}
+
+		// Set the icon
+		setError := client.SetIcon( ctx, iconHash, image )
+		if ( setError != nil ) {
+			exitWithErrorMessage( setError.Error() )
+		}
+
+		// Check it was saved
+		smartPlug := readBackProperties( ctx, client )
+		if ( !strings.EqualFold( smartPlug.Icon, iconHash ) ) {
+			exitWithErrorMessage( fmt.Sprintf( "The smart plug reports icon '%s' instead of '%s'.", smartPlug.Icon, iconHash ) )
+		}
+
+		displayProperty( outputFormat, "icon", smartPlug.Icon, fmt.Sprintf( "Icon: '%s'.", smartPlug.Icon ) )
+
+	// Require a valid property
+	} else {
+		exitWithErrorMessage( "Invalid property, must be either 'alias', 'location' or 'icon'." )
+	}
+
+}
+
+// Fetches the system information again after a change
+func readBackProperties( ctx context.Context, client *kasa.Client ) ( kasa.SmartPlug ) {
+	smartPlug, infoError := client.GetSystemInformation( ctx )
+	if ( infoError != nil ) {
+		exitWithErrorMessage( fmt.Sprintf( "Could not check the change was saved: %s", infoError ) )
+	}
+
+	return smartPlug
+}
+
+// Displays a property that was set, either as JSON or as a human-readable line
+func displayProperty( outputFormat string, name string, value any, line string ) {
+	if ( outputFormat == "json" ) {
+		printJSON( map[string]any{ name: value } )
+		return
+	}
+
+	fmt.Println( line )
+}
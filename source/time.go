@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/viral32111/kasa-smart-plug/source/kasa"
+)
+
+// Structure for the clock of a smart plug, compared to this computer's clock
+type clockReport struct {
+	Address net.IP `json:"address"`
+	Time *time.Time `json:"time,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+	Skew *float64 `json:"skew,omitempty"` // seconds ahead of this computer, negative if behind
+	Error string `json:"error,omitempty"`
+}
+
+// Shows or sets the clock of each smart plug, in parallel
+func runTimeCommand( ctx context.Context, plugAddresses []net.IP, newClient func( net.IP ) *kasa.Client, commandArguments []string, outputFormat string ) {
+
+	// Default to showing the clocks
+	action := "show"
+	if ( len( commandArguments ) > 0 ) {
+		action = commandArguments[ 0 ]
+	}
+
+	// Require a valid action & its arguments
+	location := localLocation()
+	if ( action == "show" ) {
+		if ( len( commandArguments ) > 1 ) {
+			exitWithErrorMessage( "Time show command does not require any arguments." )
+		}
+	} else if ( action == "sync" ) {
+		if ( len( commandArguments ) > 2 ) {
+			exitWithErrorMessage( "Time sync command accepts at most 1 argument for the IANA timezone." )
+		}
+
+		// Use the given timezone instead of this computer's timezone
+		if ( len( commandArguments ) == 2 ) {
+			var loadError error
+			location, loadError = time.LoadLocation( commandArguments[ 1 ] )
+			if ( loadError != nil ) {
+				exitWithErrorMessage( fmt.Sprintf( "Unknown IANA timezone '%s'.", commandArguments[ 1 ] ) )
+			}
+		}
+
+		// Fail early if the smart plugs cannot represent the timezone
+		_, indexError := kasa.TimezoneIndex( location )
+		if ( indexError != nil ) {
+			exitWithErrorMessage( fmt.Sprintf( "Timezone '%s' is not supported by smart plugs.", location ) )
+		}
+	} else {
+		exitWithErrorMessage( "Invalid time action, must be either 'show' or 'sync'." )
+	}
+
+	// Show or set the clock of every smart plug at once, so one that is offline does not hold up the others
+	reports := make( []clockReport, len( plugAddresses ) )
+	waitGroup := sync.WaitGroup{}
+	for index, plugAddress := range plugAddresses {
+		waitGroup.Add( 1 )
+		go func( index int, plugAddress net.IP ) {
+			defer waitGroup.Done()
+			reports[ index ] = readClock( ctx, newClient( plugAddress ), action == "sync", location )
+		}( index, plugAddress )
+	}
+	waitGroup.Wait()
+
+	// Display the clocks as JSON if requested
+	failed := false
+	if ( outputFormat == "json" ) {
+		printJSON( reports )
+
+		for _, report := range reports {
+			failed = failed || report.Error != ""
+		}
+
+	// Otherwise, display each clock on its own line
+	} else {
+		for _, report := range reports {
+			if ( report.Error != "" ) {
+				fmt.Fprintf( os.Stderr, "%s\tError: %s\n", report.Address, report.Error )
+				failed = true
+				continue
+			}
+
+			fmt.Printf( "%s\t%s\t%s\t%+.0fs\n", report.Address, report.Time.Format( time.RFC1123Z ), report.Timezone, *report.Skew )
+		}
+	}
+
+	// Exit with a failure status code if any smart plug could not be reached
+	if ( failed ) {
+		os.Exit( 1 )
+	}
+
+}
+
+// Optionally sets the clock of a smart plug from this computer's clock, then reads it back to compare
+func readClock( ctx context.Context, client *kasa.Client, setClock bool, location *time.Location ) ( clockReport ) {
+	report := clockReport{ Address: client.Address }
+
+	// Connect to the smart plug
+	connectError := client.Connect( ctx )
+	if ( connectError != nil ) {
+		report.Error = connectError.Error()
+		return report
+	}
+	defer client.Close()
+
+	// Set the timezone & clock together, if requested
+	if ( setClock ) {
+		setError := client.SetTimezone( ctx, location, time.Now() )
+		if ( setError != nil ) {
+			report.Error = setError.Error()
+			return report
+		}
+	}
+
+	// Fetch the clock, comparing against this computer's clock half-way through the query
+	sentAt := time.Now()
+	plugTime, timeError := client.GetTime( ctx )
+	if ( timeError != nil ) {
+		report.Error = timeError.Error()
+		return report
+	}
+	hostTime := sentAt.Add( time.Since( sentAt ) / 2 )
+
+	// The smart plug only has a precision of seconds
+	skew := plugTime.Sub( hostTime ).Round( time.Second ).Seconds()
+	report.Time = &plugTime
+	report.Timezone = plugTime.Location().String()
+	report.Skew = &skew
+
+	return report
+}
+
+// Returns this computer's timezone by its IANA name where possible, as the name of time.Local is only "Local", which cannot tell apart timezones with the same offsets
+func localLocation() ( *time.Location ) {
+
+	// Prefer the TZ environment variable, which may start with a colon
+	timezoneName := strings.TrimPrefix( os.Getenv( "TZ" ), ":" )
+
+	// Otherwise, use where /etc/localtime links to within the timezone database, like /usr/share/zoneinfo/Europe/Paris
+	if ( timezoneName == "" ) {
+		linkPath, linkError := os.Readlink( "/etc/localtime" )
+		_, linkName, isInDatabase := strings.Cut( linkPath, "zoneinfo/" )
+		if ( linkError == nil && isInDatabase ) {
+			timezoneName = linkName
+		}
+	}
+
+	// Otherwise, use the name in /etc/timezone, on systems where /etc/localtime is a copy
+	if ( timezoneName == "" ) {
+		timezoneBytes, readError := os.ReadFile( "/etc/timezone" )
+		if ( readError == nil ) {
+			timezoneName = strings.TrimSpace( string( timezoneBytes ) )
+		}
+	}
+
+	// Load the timezone by its name, falling back to the local timezone to be matched by its offsets
+	if ( timezoneName != "" ) {
+		location, loadError := time.LoadLocation( timezoneName )
+		if ( loadError == nil ) {
+			return location
+		}
+	}
+
+	return time.Local
+
+}
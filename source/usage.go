@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/viral32111/kasa-smart-plug/source/kasa"
+)
+
+// Structure for the real-time energy usage, for the JSON output format
+type realtimeUsageReport struct {
+	Wattage float64 `json:"wattage"` // watts
+	Voltage float64 `json:"voltage"` // volts
+	Amperage float64 `json:"amperage"` // amps
+	Total float64 `json:"total"` // kilowatt-hours
+}
+
+// Structure for the energy usage over a period, for the JSON output format
+type periodUsageReport struct {
+	Days int `json:"days"`
+	Total float64 `json:"total"` // kilowatt-hours
+	Average float64 `json:"average"` // kilowatt-hours per day
+	Daily []dailyUsageReport `json:"daily"`
+}
+
+// Structure for the energy used on a single day, for the JSON output format
+type dailyUsageReport struct {
+	Date string `json:"date"` // YYYY-MM-DD
+	Total float64 `json:"total"` // kilowatt-hours
+}
+
+// Displays the real-time energy usage, or the total or average energy usage over the last number of days
+func runUsageCommand( ctx context.Context, client *kasa.Client, usageType string, usagePeriod int, outputFormat string ) {
+
+	// Display the real-time energy usage
+	if ( usageType == "now" ) {
+		energyUsage, energyUsageError := client.GetEnergyUsage( ctx )
+		if ( energyUsageError != nil ) {
+			exitWithErrorMessage( energyUsageError.Error() )
+		}
+
+		report := realtimeUsageReport{
+			Wattage: energyUsage.Wattage,
+			Voltage: energyUsage.Voltage,
+			Amperage: energyUsage.Amperage,
+			Total: float64( energyUsage.Total ) / 1000.0,
+		}
+
+		if ( outputFormat == "json" ) {
+			printJSON( report )
+			return
+		}
+
+		fmt.Printf( "Wattage: %.2f W.\n", report.Wattage )
+		fmt.Printf( "Voltage: %.2f V.\n", report.Voltage )
+		fmt.Printf( "Amperage: %.3f A.\n", report.Amperage )
+		fmt.Printf( "Total: %.3f kWh.\n", report.Total )
+
+		return
+	}
+
+	// Fetch each day in the period, which may span more than one month
+	dailyUsages, dailyUsageError := client.GetRecentDailyUsage( ctx, usagePeriod )
+	if ( dailyUsageError != nil ) {
+		exitWithErrorMessage( dailyUsageError.Error() )
+	}
+
+	// Add up the days
+	report := periodUsageReport{ Days: usagePeriod, Daily: make( []dailyUsageReport, len( dailyUsages ) ) }
+	for index, dailyUsage := range dailyUsages {
+		report.Daily[ index ] = dailyUsageReport{
+			Date: fmt.Sprintf( "%04d-%02d-%02d", dailyUsage.Year, dailyUsage.Month, dailyUsage.Day ),
+			Total: float64( dailyUsage.Total ) / 1000.0,
+		}
+		report.Total += report.Daily[ index ].Total
+	}
+	report.Average = report.Total / float64( usagePeriod )
+
+	// Display the days as JSON if requested
+	if ( outputFormat == "json" ) {
+		printJSON( report )
+		return
+	}
+
+	// Display each day on its own line, then the total or average
+	for _, daily := range report.Daily {
+		fmt.Printf( "%s\t%.3f kWh\n", daily.Date, daily.Total )
+	}
+	if ( usageType == "total" ) {
+		fmt.Printf( "Total over the last %d days: %.3f kWh.\n", usagePeriod, report.Total )
+	} else {
+		fmt.Printf( "Average over the last %d days: %.3f kWh per day.\n", usagePeriod, report.Average )
+	}
+
+}
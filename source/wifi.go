@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/viral32111/kasa-smart-plug/source/kasa"
+)
+
+// How long to wait for a smart plug to appear on the wireless network it was told to join
+const wifiJoinTimeout = 2 * time.Minute
+
+// Structure for the result of joining a wireless network, for the JSON output format
+type wifiJoinReport struct {
+	SSID string `json:"ssid"`
+	KeyType string `json:"key_type"`
+	Verified bool `json:"verified"`
+	Address net.IP `json:"address,omitempty"`
+}
+
+// Lists the wireless networks a smart plug can see, or joins it to one
+func runWiFiCommand( ctx context.Context, client *kasa.Client, discovery *kasa.Discovery, commandArguments []string, outputFormat string ) {
+
+	// Default to listing the wireless networks
+	action := "scan"
+	actionArguments := []string{}
+	if ( len( commandArguments ) > 0 ) {
+		action, actionArguments = commandArguments[ 0 ], commandArguments[ 1 : ]
+	}
+
+	// Is this to list the wireless networks?
+	if ( action == "scan" ) {
+
+		// Require no arguments
+		if ( len( actionArguments ) > 0 ) {
+			exitWithErrorMessage( "Wi-Fi scan command does not require any arguments." )
+		}
+
+		// Scan for wireless networks
+		accessPoints, scanError := client.ScanWiFi( ctx, true )
+		if ( scanError != nil ) {
+			exitWithErrorMessage( scanError.Error() )
+		}
+
+		// Display the wireless networks as JSON if requested
+		if ( outputFormat == "json" ) {
+			printJSON( accessPoints )
+			return
+		}
+
+		// Display each wireless network on its own line
+		if ( len( accessPoints ) == 0 ) {
+			fmt.Println( "No wireless networks found." )
+		}
+		for _, accessPoint := range accessPoints {
+			fmt.Printf( "%s\t'%s'\n", accessPoint.KeyType, accessPoint.SSID )
+		}
+
+	// Is this to join a wireless network?
+	} else if ( action == "join" ) {
+
+		// Require the network name, with an optional password & security type
+		if ( len( actionArguments ) < 1 || len( actionArguments ) > 3 ) {
+			exitWithErrorMessage( "Wi-Fi join command requires an argument for the network name, then optionally the password & security type." )
+		}
+
+		joinWiFi( ctx, client, discovery, actionArguments, outputFormat )
+
+	// Require a valid action
+	} else {
+		exitWithErrorMessage( "Invalid Wi-Fi action, must be either 'scan' or 'join'." )
+	}
+
+}
+
+// Joins a smart plug to a wireless network, then waits for it to appear there
+func joinWiFi( ctx context.Context, client *kasa.Client, discovery *kasa.Discovery, actionArguments []string, outputFormat string ) {
+	ssid := actionArguments[ 0 ]
+
+	// Reports progress, only for the human-readable output format
+	progress := func( format string, arguments ...any ) {
+		if ( outputFormat == "human" ) {
+			fmt.Printf( format + "\n", arguments... )
+		}
+	}
+
+	// Remember which smart plug this is, to recognise it on the new network
+	smartPlug, infoError := client.GetSystemInformation( ctx )
+	if ( infoError != nil ) {
+		exitWithErrorMessage( infoError.Error() )
+	}
+	progress( "Connected to smart plug '%s' (%s) at %s.", smartPlug.Alias, smartPlug.MACAddress, client.Address )
+
+	// Use the given security type, otherwise use whatever the network advertises
+	var keyType kasa.KeyType
+	if ( len( actionArguments ) > 2 ) {
+		parsedKeyType, parseError := kasa.ParseKeyType( actionArguments[ 2 ] )
+		if ( parseError != nil ) {
+			exitWithErrorMessage( "Invalid security type, must be either 'none', 'wep', 'wpa' or 'wpa2'." )
+		}
+
+		keyType = parsedKeyType
+	} else {
+		progress( "Scanning for wireless network '%s'...", ssid )
+
+		accessPoints, scanError := client.ScanWiFi( ctx, true )
+		if ( scanError != nil ) {
+			exitWithErrorMessage( scanError.Error() )
+		}
+
+		found := false
+		for _, accessPoint := range accessPoints {
+			if ( accessPoint.SSID == ssid ) {
+				keyType, found = accessPoint.KeyType, true
+				break
+			}
+		}
+		if ( !found ) {
+			exitWithErrorMessage( fmt.Sprintf( "The smart plug cannot see wireless network '%s', check the name or give the security type to join it anyway.", ssid ) )
+		}
+
+		progress( "Found wireless network '%s' using %s security.", ssid, keyType )
+	}
+
+	// Use the given password, otherwise ask for it unless the network is open
+	password := ""
+	if ( len( actionArguments ) > 1 ) {
+		password = actionArguments[ 1 ]
+	} else if ( keyType != kasa.KeyTypeNone ) {
+		password = askForInput( fmt.Sprintf( "Password for wireless network '%s': ", ssid ) )
+	}
+
+	// Send the network settings
+	joinError := client.JoinWiFi( ctx, ssid, password, keyType )
+	if ( joinError != nil ) {
+		exitWithErrorMessage( joinError.Error() )
+	}
+	client.Close()
+	progress( "Sent the network settings, the smart plug is now leaving its own network to join '%s'.", ssid )
+	progress( "Reconnect this computer to '%s', waiting up to %s for the smart plug to appear...", ssid, wifiJoinTimeout )
+
+	// Scan the new network until the smart plug appears, or give up
+	report := wifiJoinReport{ SSID: ssid, KeyType: keyType.String() }
+	deadline := time.Now().Add( wifiJoinTimeout )
+	for ( !report.Verified && time.Now().Before( deadline ) ) {
+		devices, discoverError := discovery.Run( ctx )
+
+		// The network may not be reachable while this computer is switching over, so wait & try again
+		if ( discoverError != nil ) {
+			time.Sleep( discovery.Window )
+			continue
+		}
+
+		for _, device := range devices {
+			if ( strings.EqualFold( device.MACAddress, smartPlug.MACAddress ) ) {
+				report.Verified, report.Address = true, device.Address
+			}
+		}
+	}
+
+	// Display the result as JSON if requested
+	if ( outputFormat == "json" ) {
+		printJSON( report )
+	} else if ( report.Verified ) {
+		progress( "The smart plug joined '%s' & is now at %s.", ssid, report.Address )
+	}
+
+	// Fail if the smart plug never appeared
+	if ( !report.Verified ) {
+		exitWithErrorMessage( fmt.Sprintf( "The smart plug did not appear on '%s', check the password then reset it to try again.", ssid ) )
+	}
+
+}